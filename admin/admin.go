@@ -0,0 +1,170 @@
+// Package admin provides a module serving the administrative HTTP endpoints of an application.
+//
+// The module is added like any other module:
+//
+//	application.Run("example", "1.0.0", application.WithModule("admin", admin.New()))
+//
+// and serves the following endpoints:
+//
+//	/healthz       liveness of the modules, 503 when any module is unhealthy
+//	/readyz        readiness of the modules, 503 until every module has started
+//	/modules       JSON listing of the modules with their lifecycle state, transitions and timings
+//	/version       the application name/version
+//	/debug/pprof/  net/http/pprof handlers, when enabled
+//
+// The listen address and pprof handlers are configured by the listen and pprof attributes:
+//
+//	listen = ":8081"
+//	pprof  = true
+//
+// which are set in an admin block instead when the application is run with application.WithModuleBlocks.
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/portcullis/application"
+)
+
+// DefaultListen is the address the admin endpoints are served on when not configured
+const DefaultListen = ":8081"
+
+// Config of the admin module
+type Config struct {
+	Listen string `config:"listen,optional"`
+	Pprof  bool   `config:"pprof,optional"`
+}
+
+// Module serving the admin endpoints
+type Module struct {
+	config   Config
+	app      *application.Application
+	server   *http.Server
+	listener net.Listener
+}
+
+// New admin module listening on the DefaultListen address
+func New() *Module {
+	return &Module{
+		config: Config{
+			Listen: DefaultListen,
+		},
+	}
+}
+
+// Config returns the configuration to decode the listen and pprof attributes into
+func (m *Module) Config() (interface{}, error) {
+	return &m.config, nil
+}
+
+// Initialize captures the application the module is running in
+func (m *Module) Initialize(ctx context.Context) (context.Context, error) {
+	m.app = application.FromContext(ctx)
+	if m.app == nil {
+		return ctx, errors.New("admin module must be run by an application")
+	}
+
+	return ctx, nil
+}
+
+// Start listening for admin requests
+func (m *Module) Start(ctx context.Context) error {
+	listen := m.listen()
+
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %q: %w", listen, err)
+	}
+
+	m.listener = listener
+	m.server = &http.Server{Handler: m.Handler()}
+
+	go func() {
+		if err := m.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			m.app.Exit(fmt.Errorf("admin server failed: %w", err))
+		}
+	}()
+
+	return nil
+}
+
+// Stop serving admin requests, waiting for active requests until the context is done
+func (m *Module) Stop(ctx context.Context) error {
+	if m.server == nil {
+		return nil
+	}
+
+	return m.server.Shutdown(ctx)
+}
+
+// Addr the admin endpoints are served on, nil when not started
+func (m *Module) Addr() net.Addr {
+	if m.listener == nil {
+		return nil
+	}
+
+	return m.listener.Addr()
+}
+
+// Handler for the admin endpoints
+func (m *Module) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		report := m.app.Controller.Health(r.Context())
+		writeJSON(w, statusCode(report.Live), report)
+	})
+
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		report := m.app.Controller.Health(r.Context())
+		writeJSON(w, statusCode(report.Ready), report)
+	})
+
+	mux.HandleFunc("/modules", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, m.app.Controller.Statuses())
+	})
+
+	mux.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, m.app.String())
+	})
+
+	if m.config.Pprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+
+	return mux
+}
+
+func (m *Module) listen() string {
+	if m.config.Listen == "" {
+		return DefaultListen
+	}
+
+	return m.config.Listen
+}
+
+func statusCode(ok bool) int {
+	if ok {
+		return http.StatusOK
+	}
+
+	return http.StatusServiceUnavailable
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,91 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/portcullis/application"
+)
+
+func TestAdmin(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.hcl")
+	config := `
+listen = "127.0.0.1:0"
+pprof  = true
+`
+	if err := os.WriteFile(filename, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New()
+	app := application.New("test", "1.2.3", application.WithConfigFile(filename), application.WithModule("admin", m))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- app.Run(ctx) }()
+
+	for i := 0; !app.Controller.Ready() && i < 500; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !app.Controller.Ready() {
+		t.Fatal("application did not start")
+	}
+
+	tests := []struct {
+		Path     string
+		Code     int
+		Contains string
+	}{
+		{Path: "/healthz", Code: http.StatusOK, Contains: `"live":true`},
+		{Path: "/readyz", Code: http.StatusOK, Contains: `"ready":true`},
+		{Path: "/version", Code: http.StatusOK, Contains: "test/1.2.3"},
+		{Path: "/debug/pprof/", Code: http.StatusOK, Contains: "goroutine"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Path, func(t *testing.T) {
+			resp, err := http.Get("http://" + m.Addr().String() + test.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != test.Code {
+				t.Errorf("unexpected status: expected %d; got %d", test.Code, resp.StatusCode)
+			}
+
+			if !strings.Contains(string(body), test.Contains) {
+				t.Errorf("expected body to contain %q; got %s", test.Contains, body)
+			}
+		})
+	}
+
+	resp, err := http.Get("http://" + m.Addr().String() + "/modules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var modules []application.ModuleStatus
+	if err := json.NewDecoder(resp.Body).Decode(&modules); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(modules) != 1 || modules[0].Name != "admin" || modules[0].State != application.StateRunning {
+		t.Errorf("unexpected modules: %+v", modules)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
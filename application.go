@@ -2,20 +2,28 @@ package application
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
-	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/hashicorp/hcl/v2"
+	"github.com/portcullis/application/confighcl"
 	"github.com/portcullis/config"
 )
 
+// SampleConfigCommand is the command line argument that writes the sample configuration of the modules to standard
+// output instead of running the application when commands are enabled by WithCommands or WithFlags, see
+// Application.SampleConfig
+const SampleConfigCommand = "sample-config"
+
 // Application defines an instance of an application
 type Application struct {
 	sync.Mutex
@@ -27,8 +35,14 @@ type Application struct {
 	Logger     *slog.Logger
 
 	configuration *Configuration
-	configFile    string
+	configFiles   []string
+	flags         bool
+	commands      bool
+	args          []string
+	output        io.Writer
+	stdout        io.Writer
 	errorCh       chan error
+	reloadLock    sync.Mutex
 }
 
 // Run creates an application with the specified name and version, applies the provided options, and begins execution
@@ -43,6 +57,8 @@ func New(name, version string, opts ...Option) *Application {
 		Version:    version,
 		Controller: &Controller{},
 		Logger:     slog.Default(),
+		output:     os.Stderr,
+		stdout:     os.Stdout,
 	}
 
 	for _, opt := range opts {
@@ -60,7 +76,16 @@ func (a *Application) Validate(ctx context.Context) error {
 	a.Lock()
 	defer a.Unlock()
 
-	return a.loadConfig(a.initialize(ctx))
+	ctx = a.initialize(ctx)
+	if err := a.parseArgs(ctx); err != nil {
+		return err
+	}
+
+	if err := a.loadConfig(ctx); err != nil {
+		return err
+	}
+
+	return a.Controller.Validate()
 }
 
 // Install will execute all modules that have an application.Initializer implementation, then all modules with that implement the application.Installer
@@ -69,11 +94,19 @@ func (a *Application) Install(ctx context.Context) error {
 	defer a.Unlock()
 
 	ctx = a.initialize(ctx)
+	if err := a.parseArgs(ctx); err != nil {
+		return err
+	}
+
 	if err := a.loadConfig(ctx); err != nil {
 		return err
 	}
 
-	initializeModules := sortModules(a.Controller.modules)
+	initializeModules, err := a.Controller.sorted()
+	if err != nil {
+		return err
+	}
+
 	for _, im := range initializeModules {
 		if initializer, ok := im.implementation.(Initializer); ok {
 			itx, err := initializer.Initialize(ctx)
@@ -87,7 +120,11 @@ func (a *Application) Install(ctx context.Context) error {
 		}
 	}
 
-	installModules := sortModules(a.Controller.modules)
+	installModules, err := a.Controller.sorted()
+	if err != nil {
+		return err
+	}
+
 	for _, im := range installModules {
 		installer, ok := im.implementation.(Installer)
 		if !ok {
@@ -108,6 +145,20 @@ func (a *Application) Run(ctx context.Context) error {
 	defer a.Unlock()
 
 	ctx = a.initialize(ctx)
+	if err := a.parseArgs(ctx); err != nil {
+		// the flag package has written the usage, so asking for it is not an error
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to parse command line flags: %w", err)
+	}
+
+	if a.command() == SampleConfigCommand {
+		_, err := a.stdout.Write(a.configuration.SampleConfig(ctx))
+		return err
+	}
+
 	if err := a.loadConfig(ctx); err != nil {
 		return err
 	}
@@ -162,8 +213,8 @@ APP_RUN:
 		case sig := <-schan:
 			a.Logger.Debug("Signal received", "signal", sig)
 			if sig == syscall.SIGHUP || sig == syscall.Signal(21) {
-				a.Logger.Debug("TODO: Implement application reload hooks")
-				// TODO: implement reload
+				// failures are logged by Reload and the application continues with the current configuration
+				_ = a.Reload(ctx)
 				break
 			}
 
@@ -175,14 +226,78 @@ APP_RUN:
 	// wait for finalization of Controller shutdown
 	wg.Wait()
 
-	// this is a bit of a hacky thing, but allows us to not return errors for help command line and invalid commands so the top level caller can if err != nil panic(err)
-	if applicationError != nil && applicationError != context.Canceled && !errors.Is(applicationError, flag.ErrHelp) && !strings.Contains(applicationError.Error(), "flag provided but not defined:") {
+	// this is a bit of a hacky thing, but allows us to not return errors for help command line so the top level caller can if err != nil panic(err)
+	if applicationError != nil && applicationError != context.Canceled && !errors.Is(applicationError, flag.ErrHelp) {
 		return applicationError
 	}
 
 	return nil
 }
 
+// Reload the configuration files and pass the newly decoded configuration to every module implementing Reloader.
+//
+// When the configuration fails to decode, the diagnostics are logged and returned and the modules keep their current configuration.
+func (a *Application) Reload(ctx context.Context) error {
+	a.reloadLock.Lock()
+	defer a.reloadLock.Unlock()
+
+	if a.configuration == nil || len(a.configFiles) == 0 {
+		return nil
+	}
+
+	if FromContext(ctx) != a {
+		ctx = context.WithValue(ctx, applicationContextKey, a)
+	}
+
+	ts := time.Now()
+	a.Logger.Info("Reloading configuration", "files", a.configFiles)
+	if err := a.configuration.ReloadFiles(ctx, a.configFiles...); err != nil {
+		a.Logger.Error("Failed to reload configuration", "files", a.configFiles, "error", err)
+		return fmt.Errorf("failed to reload application configuration: %w", err)
+	}
+	a.Logger.Info("Reloaded configuration", "files", a.configFiles, "duration", time.Since(ts))
+
+	return nil
+}
+
+// EnvVars lists the environment variables that override the module configurations, when enabled with WithEnvOverrides
+func (a *Application) EnvVars() []confighcl.EnvVar {
+	if a.configuration == nil {
+		return nil
+	}
+
+	return a.configuration.EnvVars(context.WithValue(context.Background(), applicationContextKey, a))
+}
+
+// JSONSchema returns the JSON Schema document of the configuration files of the modules in the JSON syntax, for
+// validating them in editors and before deployments
+func (a *Application) JSONSchema() ([]byte, error) {
+	schema := a.configurationOrDefault().JSONSchema(context.WithValue(context.Background(), applicationContextKey, a))
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// SampleConfig returns a commented HCL configuration file with the default configuration of every Configurable module,
+// which is also written by running the application with the sample-config argument
+func (a *Application) SampleConfig() []byte {
+	return a.configurationOrDefault().SampleConfig(context.WithValue(context.Background(), applicationContextKey, a))
+}
+
+// ConfigReference returns Markdown reference documentation of the configuration of every Configurable module, with
+// the types, defaults and description tags of their attributes and blocks
+func (a *Application) ConfigReference() []byte {
+	return a.configurationOrDefault().Reference(context.WithValue(context.Background(), applicationContextKey, a))
+}
+
+// configurationOrDefault returns the configuration of the application, or a configuration with the default options
+func (a *Application) configurationOrDefault() *Configuration {
+	if a.configuration == nil {
+		return &Configuration{}
+	}
+
+	return a.configuration
+}
+
 // Exit will shutdown the application with the specified error.
 //
 // This call can be made from any go routine, only the first call to Exit will be read (first in) and shutdown the application
@@ -218,19 +333,62 @@ func (a *Application) initialize(ctx context.Context) context.Context {
 	return ctx
 }
 
+// parseArgs parses the command line flags when they are enabled, recording the remaining arguments for command. The
+// arguments are ignored unless flags or commands are enabled.
+func (a *Application) parseArgs(ctx context.Context) error {
+	if a.configuration == nil || (!a.flags && !a.commands) {
+		return nil
+	}
+
+	args := a.args
+	if args == nil {
+		args = os.Args[1:]
+	}
+
+	if !a.flags {
+		a.configuration.args = args
+		return nil
+	}
+
+	return a.configuration.ParseFlags(ctx, args)
+}
+
+// command returns the first argument that is not a flag, if any
+func (a *Application) command() string {
+	if a.configuration == nil || len(a.configuration.args) == 0 {
+		return ""
+	}
+
+	return a.configuration.args[0]
+}
+
 func (a *Application) loadConfig(ctx context.Context) error {
 	if a.configuration == nil {
 		return nil
 	}
 
-	if a.configFile == "" {
+	if len(a.configFiles) == 0 {
+		if !a.configuration.overrides() {
+			return nil
+		}
+
+		// the overrides still apply to the values the modules start with
+		if diags := a.configuration.DecodeBody(ctx, hcl.EmptyBody()); diags.HasErrors() {
+			return fmt.Errorf("failed to load application configuration: %w", diags)
+		}
+
 		return nil
 	}
 
-	a.Logger.Info("Loading configuration", "file", a.configFile)
-	if diags := a.configuration.DecodeFile(ctx, a.configFile); diags.HasErrors() {
+	a.Logger.Info("Loading configuration", "files", a.configFiles)
+	diags := a.configuration.DecodeFiles(ctx, a.configFiles...)
+	if diags.HasErrors() {
 		return fmt.Errorf("failed to load application configuration: %w", diags)
 	}
 
+	for _, diag := range diags {
+		a.Logger.Warn("Configuration warning", "warning", diag.Error())
+	}
+
 	return nil
 }
@@ -0,0 +1,145 @@
+package application
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// moduleBlockType is the type of the blocks configuring a module by any name, such as module "http" {}
+const moduleBlockType = "module"
+
+// moduleHeader is the header of a block configuring a module
+type moduleHeader struct {
+	blockType string
+	labels    []string
+}
+
+// String returns the header as written in configuration files, such as http "public"
+func (h moduleHeader) String() string {
+	header := h.blockType
+	for _, label := range h.labels {
+		header += fmt.Sprintf(" %q", label)
+	}
+
+	return header
+}
+
+// moduleHeaders returns the shortest header of the block configuring each of the named modules: a block named by the
+// module such as http {}, a block named by the part of the name before a dot labeled by the rest such as
+// http "public" {} for http.public, or a module block labeled by the name
+func moduleHeaders(names []string) map[string]moduleHeader {
+	plain := make(map[string]bool, len(names))
+	for _, name := range names {
+		plain[name] = hclsyntax.ValidIdentifier(name) && name != moduleBlockType
+	}
+
+	headers := make(map[string]moduleHeader, len(names))
+	for _, name := range names {
+		if plain[name] {
+			headers[name] = moduleHeader{blockType: name}
+			continue
+		}
+
+		// the labeled blocks of a type cannot share the name of another module
+		if blockType, label, ok := strings.Cut(name, "."); ok && hclsyntax.ValidIdentifier(blockType) && blockType != moduleBlockType && !plain[blockType] {
+			headers[name] = moduleHeader{blockType: blockType, labels: []string{label}}
+			continue
+		}
+
+		headers[name] = moduleHeader{blockType: moduleBlockType, labels: []string{name}}
+	}
+
+	return headers
+}
+
+// moduleBlocks finds the block configuring each of the named modules in the body, written with the header from
+// moduleHeaders or as a module block, and returns the remaining body
+func moduleBlocks(body hcl.Body, names []string) (map[string]*hcl.Block, hcl.Body, hcl.Diagnostics) {
+	headers := moduleHeaders(names)
+
+	schema := &hcl.BodySchema{Blocks: []hcl.BlockHeaderSchema{{Type: moduleBlockType, LabelNames: []string{"name"}}}}
+	declared := map[string]bool{moduleBlockType: true}
+	for _, name := range names {
+		header := headers[name]
+		if declared[header.blockType] {
+			continue
+		}
+
+		var labels []string
+		if len(header.labels) > 0 {
+			labels = []string{"name"}
+		}
+
+		declared[header.blockType] = true
+		schema.Blocks = append(schema.Blocks, hcl.BlockHeaderSchema{Type: header.blockType, LabelNames: labels})
+	}
+
+	content, remain, diags := body.PartialContent(schema)
+
+	blocks := make(map[string]*hcl.Block, len(names))
+	for _, block := range content.Blocks {
+		name := block.Type
+		if len(block.Labels) > 0 {
+			name = block.Labels[0]
+			if block.Type != moduleBlockType {
+				name = block.Type + "." + name
+			}
+		}
+
+		if _, ok := headers[name]; !ok {
+			subject := block.DefRange
+			if len(block.LabelRanges) > 0 {
+				subject = block.LabelRanges[0]
+			}
+
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unknown module",
+				Detail:   fmt.Sprintf("There is no configurable module named %q.", name),
+				Subject:  subject.Ptr(),
+			})
+			continue
+		}
+
+		if existing := blocks[name]; existing != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate module block",
+				Detail:   fmt.Sprintf("The %s module was already configured at %s.", name, existing.DefRange),
+				Subject:  block.DefRange.Ptr(),
+			})
+			continue
+		}
+
+		blocks[name] = block
+	}
+
+	return blocks, remain, diags
+}
+
+// moduleBody returns the body of the block configuring the module, or an empty body at the missing item range of the
+// configuration when there is none so required arguments are reported there
+func moduleBody(blocks map[string]*hcl.Block, name string, body hcl.Body) hcl.Body {
+	if block, ok := blocks[name]; ok {
+		return block.Body
+	}
+
+	missing := body.MissingItemRange()
+	return &hclsyntax.Body{SrcRange: missing, EndRange: missing}
+}
+
+// configurableNames returns the names of the Configurable modules of the application
+func configurableNames(app *Application) []string {
+	var names []string
+	app.Controller.Range(func(name string, m Module) bool {
+		if _, ok := m.(Configurable); ok {
+			names = append(names, name)
+		}
+		return true
+	})
+
+	return names
+}
@@ -0,0 +1,187 @@
+package confighcl
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// specialModes are the file modes of the setuid, setgid and sticky bits of octal file modes
+var specialModes = map[uint64]os.FileMode{
+	04000: os.ModeSetuid,
+	02000: os.ModeSetgid,
+	01000: os.ModeSticky,
+}
+
+func init() {
+	registerString(durationType, "duration", func(s string) (interface{}, error) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, errors.New(strings.TrimPrefix(err.Error(), "time: "))
+		}
+		return d, nil
+	}, func(v interface{}) string {
+		return v.(time.Duration).String()
+	})
+
+	registerString(reflect.TypeOf(ByteSize(0)), "byte size", func(s string) (interface{}, error) {
+		return ParseByteSize(s)
+	}, func(v interface{}) string {
+		return v.(ByteSize).String()
+	})
+
+	registerString(reflect.TypeOf(net.IP(nil)), "IP address", func(s string) (interface{}, error) {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("%q is not an IPv4 or IPv6 address", s)
+		}
+		return ip, nil
+	}, func(v interface{}) string {
+		if ip := v.(net.IP); len(ip) > 0 {
+			return ip.String()
+		}
+		return ""
+	})
+
+	registerString(reflect.TypeOf((*net.IPNet)(nil)), "CIDR prefix", func(s string) (interface{}, error) {
+		_, network, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a CIDR prefix such as 10.0.0.0/8", s)
+		}
+		return network, nil
+	}, func(v interface{}) string {
+		if network := v.(*net.IPNet); network != nil {
+			return network.String()
+		}
+		return ""
+	})
+
+	registerString(reflect.TypeOf(netip.Addr{}), "IP address", func(s string) (interface{}, error) {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IPv4 or IPv6 address: %s", s, parseReason(err))
+		}
+		return addr, nil
+	}, func(v interface{}) string {
+		if addr := v.(netip.Addr); addr.IsValid() {
+			return addr.String()
+		}
+		return ""
+	})
+
+	registerString(reflect.TypeOf(netip.Prefix{}), "CIDR prefix", func(s string) (interface{}, error) {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a CIDR prefix such as 10.0.0.0/8: %s", s, parseReason(err))
+		}
+		return prefix, nil
+	}, func(v interface{}) string {
+		if prefix := v.(netip.Prefix); prefix.IsValid() {
+			return prefix.String()
+		}
+		return ""
+	})
+
+	registerString(reflect.TypeOf((*url.URL)(nil)), "URL", func(s string) (interface{}, error) {
+		u, err := url.Parse(s)
+		if err != nil {
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			return nil, fmt.Errorf("%q is not a URL: %s", s, err)
+		}
+		return u, nil
+	}, func(v interface{}) string {
+		if u := v.(*url.URL); u != nil {
+			return u.String()
+		}
+		return ""
+	})
+
+	registerString(reflect.TypeOf((*regexp.Regexp)(nil)), "regular expression", func(s string) (interface{}, error) {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return nil, errors.New(strings.TrimPrefix(err.Error(), "error parsing regexp: "))
+		}
+		return re, nil
+	}, func(v interface{}) string {
+		if re := v.(*regexp.Regexp); re != nil {
+			return re.String()
+		}
+		return ""
+	})
+
+	registerString(reflect.TypeOf(os.FileMode(0)), "file mode", func(s string) (interface{}, error) {
+		bits, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimPrefix(s, "0o"), "0O"), 8, 32)
+		if err != nil || bits > 07777 {
+			return nil, fmt.Errorf("%q is not an octal file mode such as 0644", s)
+		}
+
+		mode := os.FileMode(bits) & os.ModePerm
+		for bit, m := range specialModes {
+			if bits&bit != 0 {
+				mode |= m
+			}
+		}
+		return mode, nil
+	}, func(v interface{}) string {
+		mode := v.(os.FileMode)
+
+		bits := uint64(mode & os.ModePerm)
+		for bit, m := range specialModes {
+			if mode&m != 0 {
+				bits |= bit
+			}
+		}
+		return fmt.Sprintf("%#o", bits)
+	})
+
+	registerString(reflect.TypeOf(time.Time{}), "timestamp", func(s string) (interface{}, error) {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an RFC 3339 timestamp such as 2006-01-02T15:04:05Z", s)
+		}
+		return t, nil
+	}, func(v interface{}) string {
+		return v.(time.Time).Format(time.RFC3339Nano)
+	})
+}
+
+// registerString registers the handler of a type whose configuration values are strings, values formatted as empty
+// strings such as nil pointers are encoded as null
+func registerString(ty reflect.Type, name string, parse func(string) (interface{}, error), format func(interface{}) string) {
+	RegisterType(ty, TypeHandler{
+		Type: cty.String,
+		Name: name,
+		Decode: func(val cty.Value) (interface{}, error) {
+			return parse(val.AsString())
+		},
+		Encode: func(v interface{}) (cty.Value, error) {
+			if s := format(v); s != "" {
+				return cty.StringVal(s), nil
+			}
+			return cty.NullVal(cty.String), nil
+		},
+	})
+}
+
+// parseReason returns the reason of a parsing error of the net/netip package, without the quoted input
+func parseReason(err error) string {
+	msg := err.Error()
+	if i := strings.LastIndex(msg, "): "); i != -1 {
+		msg = msg[i+3:]
+	}
+
+	return msg
+}
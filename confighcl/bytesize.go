@@ -0,0 +1,82 @@
+package confighcl
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// ByteSize is a number of bytes, written in configurations as a number or with a unit such as 512MiB or 1.5GB
+type ByteSize uint64
+
+// byteUnits are the multiples of the units of byte sizes, decimal and binary
+var byteUnits = map[string]uint64{
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"pb":  1e15,
+	"eb":  1e18,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+	"pib": 1 << 50,
+	"eib": 1 << 60,
+}
+
+// byteUnitNames are the units used by String, largest first
+var byteUnitNames = []string{"EiB", "PiB", "TiB", "GiB", "MiB", "KiB", "EB", "PB", "TB", "GB", "MB", "kB"}
+
+// ParseByteSize parses a number of bytes with an optional unit, which is case insensitive and may follow a space, such
+// as 1024, 512MiB or 1.5 GB. Sizes that are not a whole number of bytes are rounded down.
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i == -1 {
+		i = len(s)
+	}
+
+	num, unit := s[:i], strings.TrimSpace(s[i:])
+	if num == "" {
+		return 0, fmt.Errorf("%q is not a byte size such as 512MiB", s)
+	}
+
+	multiple := uint64(1)
+	if unit != "" {
+		var ok bool
+		if multiple, ok = byteUnits[strings.ToLower(unit)]; !ok {
+			return 0, fmt.Errorf("%q has an unknown unit %q, such as B, kB, MB, KiB or MiB", s, unit)
+		}
+	}
+
+	f, ok := new(big.Float).SetPrec(128).SetString(num)
+	if !ok {
+		return 0, fmt.Errorf("%q is not a byte size such as 512MiB", s)
+	}
+
+	size, _ := f.Mul(f, new(big.Float).SetUint64(multiple)).Int(nil)
+	if !size.IsUint64() {
+		return 0, fmt.Errorf("%q is larger than %d bytes", s, uint64(math.MaxUint64))
+	}
+
+	return ByteSize(size.Uint64()), nil
+}
+
+// String formats the size with the largest unit that is a whole multiple, such as 512MiB, or in bytes
+func (b ByteSize) String() string {
+	if b == 0 {
+		return "0B"
+	}
+
+	for _, name := range byteUnitNames {
+		if multiple := byteUnits[strings.ToLower(name)]; uint64(b)%multiple == 0 {
+			return fmt.Sprintf("%d%s", uint64(b)/multiple, name)
+		}
+	}
+
+	return fmt.Sprintf("%dB", uint64(b))
+}
@@ -3,12 +3,11 @@ package confighcl
 import (
 	"fmt"
 	"reflect"
-	"strings"
-	"time"
 
 	"github.com/zclconf/go-cty/cty"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/zclconf/go-cty/cty/convert"
 	"github.com/zclconf/go-cty/cty/gocty"
 )
@@ -111,6 +110,17 @@ func decodeBodyToStruct(body hcl.Body, ctx *hcl.EvalContext, val reflect.Value,
 		field := val.Type().Field(fieldIdx)
 		fieldV := val.Field(fieldIdx)
 
+		if attr == nil {
+			if src, hasDefault := tags.Defaults[name]; hasDefault {
+				attr = &hcl.Attribute{
+					Name:      name,
+					Expr:      defaultExpr(name, src, body.MissingItemRange()),
+					Range:     body.MissingItemRange(),
+					NameRange: body.MissingItemRange(),
+				}
+			}
+		}
+
 		if attr == nil {
 			if !exprType.AssignableTo(field.Type) {
 				continue
@@ -132,30 +142,7 @@ func decodeBodyToStruct(body hcl.Body, ctx *hcl.EvalContext, val reflect.Value,
 			fieldV.Set(reflect.ValueOf(attr.Expr))
 
 		default:
-			// special handling for time.Duration (HACK LIKE ITS GOLD)
-			if strings.EqualFold(field.Type.Name(), "Duration") {
-				stringVal := ""
-				diags = append(diags, DecodeExpression(attr.Expr, ctx, &stringVal)...)
-
-				if !diags.HasErrors() {
-					ds, err := time.ParseDuration(stringVal)
-					if err != nil {
-						diags = append(diags, &hcl.Diagnostic{
-							Severity: hcl.DiagError,
-							Summary:  "Failed to parse duration",
-							Detail:   fmt.Sprintf("Invalid duration string: %s", strings.TrimPrefix(err.Error(), "time: ")),
-							Subject:  attr.Expr.StartRange().Ptr(),
-							Context:  attr.Expr.Range().Ptr(),
-						})
-					} else {
-						fieldV.Set(reflect.ValueOf(ds))
-					}
-				}
-			} else {
-				diags = append(diags, DecodeExpression(
-					attr.Expr, ctx, fieldV.Addr().Interface(),
-				)...)
-			}
+			diags = append(diags, DecodeExpression(attr.Expr, ctx, fieldV.Addr().Interface())...)
 		}
 	}
 
@@ -190,6 +177,16 @@ func decodeBodyToStruct(body hcl.Body, ctx *hcl.EvalContext, val reflect.Value,
 			continue
 		}
 
+		if len(blocks) == 0 && tags.DefaultBlocks[typeName] {
+			// an absent block with a default is decoded from an empty body, applying the defaults of its fields
+			blocks = hcl.Blocks{{
+				Type:      typeName,
+				Body:      &hclsyntax.Body{SrcRange: body.MissingItemRange(), EndRange: body.MissingItemRange()},
+				DefRange:  body.MissingItemRange(),
+				TypeRange: body.MissingItemRange(),
+			}}
+		}
+
 		if len(blocks) == 0 {
 			if isSlice || isPtr {
 				if val.Field(fieldIdx).IsNil() {
@@ -255,6 +252,10 @@ func decodeBodyToStruct(body hcl.Body, ctx *hcl.EvalContext, val reflect.Value,
 		}
 	}
 
+	if !diags.HasErrors() {
+		diags = append(diags, validateStruct(val, tags, content.Attributes, body.MissingItemRange())...)
+	}
+
 	return leftovers, diags
 }
 
@@ -315,9 +316,30 @@ func decodeBlockToValue(block *hcl.Block, ctx *hcl.EvalContext, v reflect.Value)
 	return diags
 }
 
+// defaultExpr returns the expression of the default tag value of the attribute
+func defaultExpr(name, src string, rng hcl.Range) hcl.Expression {
+	if expr, ok := parseDefault(name, src); ok {
+		return expr
+	}
+
+	return hcl.StaticExpr(cty.StringVal(src), rng)
+}
+
+// parseDefault parses the default tag value of the attribute, values that are not constant HCL expressions such as 5s
+// or localhost are strings
+func parseDefault(name, src string) (hclsyntax.Expression, bool) {
+	expr, diags := hclsyntax.ParseExpression([]byte(src), fmt.Sprintf("default of %s", name), hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() || len(expr.Variables()) > 0 {
+		return nil, false
+	}
+
+	return expr, true
+}
+
 // DecodeExpression extracts the value of the given expression into the given
 // value. This value must be something that gocty is able to decode into,
-// since the final decoding is delegated to that package.
+// since the final decoding is delegated to that package, or a type with a
+// TypeHandler, see RegisterType.
 //
 // The given EvalContext is used to resolve any variables or functions in
 // expressions encountered while decoding. This may be nil to require only
@@ -330,8 +352,19 @@ func decodeBlockToValue(block *hcl.Block, ctx *hcl.EvalContext, v reflect.Value)
 // may still be accessed by a careful caller for static analysis and editor
 // integration use-cases.
 func DecodeExpression(expr hcl.Expression, ctx *hcl.EvalContext, val interface{}) hcl.Diagnostics {
+	if rv := reflect.ValueOf(val); rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		if handler, ok := fieldHandler(rv.Type().Elem()); ok {
+			return handler.decodeExpression(expr, ctx, rv.Elem())
+		}
+	}
+
 	srcVal, diags := expr.Value(ctx)
 
+	srcVal, secretDiags := unmarkSecret(srcVal, reflect.TypeOf(val), expr)
+	if diags = append(diags, secretDiags...); diags.HasErrors() {
+		return diags
+	}
+
 	convTy, err := gocty.ImpliedType(val)
 	if err != nil {
 		panic(fmt.Sprintf("unsuitable DecodeExpression target: %s", err))
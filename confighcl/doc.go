@@ -26,7 +26,21 @@
 //
 // "attr" fields may either be of type *hcl.Expression, in which case the raw
 // expression is assigned, or of any type accepted by gocty, in which case
-// gocty will be used to assign the value to a native Go type.
+// gocty will be used to assign the value to a native Go type. Types that
+// gocty cannot convert are decoded and encoded by a TypeHandler registered
+// with RegisterType, or as strings when they implement
+// encoding.TextUnmarshaler. The following types, and pointers to them, have
+// built-in handlers decoding strings:
+//
+//    time.Duration such as "5s"
+//    time.Time as RFC 3339 timestamps such as "2006-01-02T15:04:05Z"
+//    ByteSize such as "512MiB", "1.5GB" or 1024
+//    net.IP and netip.Addr such as "10.0.0.1" or "::1"
+//    *net.IPNet and netip.Prefix such as "10.0.0.0/8"
+//    *url.URL such as "https://example.com/path"
+//    *regexp.Regexp such as "^[a-z]+$"
+//    os.FileMode as octal modes such as "0644"
+//    Secret, the only type values marked with SecretMark are decoded into
 //
 // "block" fields may be of type *hcl.Block or hcl.Body, in which case the
 // corresponding raw value is assigned, or may be a struct that recursively
@@ -42,6 +56,49 @@
 // "optional" fields behave like "attr" fields, but they are optional
 // and will not give parsing errors if they are missing.
 //
+// A "default" option may follow the kind of "attr", "optional" and "block"
+// fields, and must be last since its value extends to the end of the tag:
+//
+//    Timeout time.Duration     `config:"timeout,optional,default=5s"`
+//    Hosts   []string          `config:"hosts,default=[\"localhost\"]"`
+//    TLS     *TLSConfig        `config:"tls,block,default"`
+//
+// An attribute with a default is not required, and when it is absent the
+// default is decoded as if it had been written in the configuration. Values
+// that are not valid constant HCL expressions, such as 5s or localhost, are
+// strings. A bare "default" on a single block without labels decodes an
+// absent block from an empty body, so the defaults of its fields apply.
+// EncodeSampleIntoBody writes the defaults of fields that have zero values,
+// while EncodeIntoBody writes the values as they are so zero values that were
+// set explicitly are kept.
+//
+// Attributes may also have a validate tag with comma-separated rules, which
+// are checked after decoding and reported as diagnostics with the range of
+// the offending attribute:
+//
+//    Port  int    `config:"port" validate:"port"`
+//    Mode  string `config:"mode,optional" validate:"oneof=plain tls"`
+//    Cert  string `config:"cert,optional" validate:"required_if=mode tls"`
+//    Name  string `config:"name" validate:"min=1,max=63,regex=^[a-z][a-z0-9-]*$"`
+//
+// The following rules are supported:
+//
+//    nonempty indicates that strings, lists and maps must not be empty
+//    min=N and max=N limit numbers, durations, and the length of strings, lists and maps
+//    oneof=a b c requires the value to be one of the space-separated values
+//    regex=expr requires a match of the regular expression, which must be the last rule
+//    port requires a port between 1 and 65535, or a host:port string with one
+//    url requires an absolute URL with a scheme and host
+//    required_if=name value requires the attribute when the named attribute has the value
+//
+// Only attributes that are set, by the configuration or a default, are
+// checked by the rules other than required_if.
+//
+// Attributes and blocks may have a description tag, which documents them in
+// MarkdownReference, ImpliedJSONSchema, EncodeSampleIntoBody and Fields:
+//
+//    Listen string `config:"listen" description:"The address to listen on"`
+//
 // "remain" can be placed on a single field that may be either of type
 // hcl.Body or hcl.Attributes, in which case any remaining body content is
 // placed into this field for delayed processing. If no "remain" field is
@@ -5,7 +5,9 @@ import (
 	"reflect"
 	"sort"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/gocty"
 )
 
@@ -45,7 +47,7 @@ func EncodeIntoBody(val interface{}, dst *hclwrite.Body) {
 	}
 
 	tags := getFieldTags(ty)
-	populateBody(rv, ty, tags, dst)
+	populateBody(rv, ty, tags, dst, false)
 }
 
 // EncodeAsBlock creates a new hclwrite.Block populated with the data from
@@ -58,6 +60,10 @@ func EncodeIntoBody(val interface{}, dst *hclwrite.Body) {
 // This function has the same constraints as EncodeIntoBody and will panic
 // if they are violated.
 func EncodeAsBlock(val interface{}, blockType string) *hclwrite.Block {
+	return encodeAsBlock(val, blockType, false)
+}
+
+func encodeAsBlock(val interface{}, blockType string, sample bool) *hclwrite.Block {
 	rv := reflect.ValueOf(val)
 	ty := rv.Type()
 	if ty.Kind() == reflect.Ptr {
@@ -78,11 +84,13 @@ func EncodeAsBlock(val interface{}, blockType string) *hclwrite.Block {
 	}
 
 	block := hclwrite.NewBlock(blockType, labels)
-	populateBody(rv, ty, tags, block.Body())
+	populateBody(rv, ty, tags, block.Body(), sample)
 	return block
 }
 
-func populateBody(rv reflect.Value, ty reflect.Type, tags *fieldTags, dst *hclwrite.Body) {
+// populateBody encodes the struct value into the body, and when sample is set comments every attribute and block and
+// includes the absent ones commented out
+func populateBody(rv reflect.Value, ty reflect.Type, tags *fieldTags, dst *hclwrite.Body, sample bool) {
 	nameIdxs := make(map[string]int, len(tags.Attributes)+len(tags.Blocks))
 	namesOrder := make([]string, 0, len(tags.Attributes)+len(tags.Blocks))
 	for n, i := range tags.Attributes {
@@ -114,9 +122,47 @@ func populateBody(rv reflect.Value, ty reflect.Type, tags *fieldTags, dst *hclwr
 
 		if _, isAttr := tags.Attributes[name]; isAttr {
 
+			if isSecretType(field.Type) {
+				if sample {
+					if prevWasBlock {
+						dst.AppendNewline()
+					}
+
+					// show where the secret is set, without writing it
+					dst.AppendUnstructuredTokens(attributeComment(name, field.Type, tags))
+					dst.AppendUnstructuredTokens(commentOut(sampleSecret(name)))
+					prevWasBlock = true
+				}
+				continue // never write secrets
+			}
+
+			if src, hasDefault := tags.Defaults[name]; sample && hasDefault && rv.Field(fieldIdx).IsZero() {
+				if prevWasBlock {
+					dst.AppendNewline()
+					prevWasBlock = false
+				}
+
+				dst.AppendUnstructuredTokens(attributeComment(name, field.Type, tags))
+				setDefaultAttribute(dst, name, src)
+				prevWasBlock = true // samples separate every commented attribute
+				continue
+			}
+
 			if exprType.AssignableTo(fieldTy) || attrType.AssignableTo(fieldTy) {
 				continue // ignore undecoded fields
 			}
+			if sample && (!fieldVal.IsValid() || isNilCollection(fieldVal)) {
+				if prevWasBlock {
+					dst.AppendNewline()
+					prevWasBlock = false
+				}
+
+				// show the zero value of absent optional attributes, without setting it
+				dst.AppendUnstructuredTokens(attributeComment(name, field.Type, tags))
+				dst.AppendUnstructuredTokens(commentOut(sampleAttribute(name, field.Type)))
+				prevWasBlock = true
+				continue
+			}
 			if !fieldVal.IsValid() {
 				continue // ignore (field value is nil pointer)
 			}
@@ -128,19 +174,11 @@ func populateBody(rv reflect.Value, ty reflect.Type, tags *fieldTags, dst *hclwr
 				prevWasBlock = false
 			}
 
-			valTy, err := gocty.ImpliedType(fieldVal.Interface())
-			if err != nil {
-				panic(fmt.Sprintf("cannot encode %T as HCL expression: %s", fieldVal.Interface(), err))
-			}
-
-			val, err := gocty.ToCtyValue(fieldVal.Interface(), valTy)
-			if err != nil {
-				// This should never happen, since we should always be able
-				// to decode into the implied type.
-				panic(fmt.Sprintf("failed to encode %T as %#v: %s", fieldVal.Interface(), valTy, err))
+			if sample {
+				dst.AppendUnstructuredTokens(attributeComment(name, field.Type, tags))
 			}
-
-			dst.SetAttributeValue(name, val)
+			setAttributeValue(dst, name, rv.Field(fieldIdx))
+			prevWasBlock = sample
 
 		} else { // must be a block, then
 			elemTy := fieldTy
@@ -155,6 +193,15 @@ func populateBody(rv reflect.Value, ty reflect.Type, tags *fieldTags, dst *hclwr
 			}
 			prevWasBlock = false
 
+			if sample && isSeq && fieldVal.Len() == 0 {
+				// show an example of absent blocks, without adding one
+				dst.AppendNewline()
+				dst.AppendUnstructuredTokens(blockComment(name, field.Type, tags))
+				dst.AppendUnstructuredTokens(commentOut(sampleBlock(name, indirectType(elemTy))))
+				prevWasBlock = true
+				continue
+			}
+
 			if isSeq {
 				l := fieldVal.Len()
 				for i := 0; i < l; i++ {
@@ -165,27 +212,93 @@ func populateBody(rv reflect.Value, ty reflect.Type, tags *fieldTags, dst *hclwr
 					if elemTy.Kind() == reflect.Ptr && elemVal.IsNil() {
 						continue // ignore
 					}
-					block := EncodeAsBlock(elemVal.Interface(), name)
+					block := encodeAsBlock(elemVal.Interface(), name, sample)
 					if !prevWasBlock {
 						dst.AppendNewline()
 						prevWasBlock = true
 					}
+					if sample && i == 0 {
+						dst.AppendUnstructuredTokens(blockComment(name, field.Type, tags))
+					}
 					dst.AppendBlock(block)
 				}
 			} else {
+				if sample && !fieldVal.IsValid() && tags.DefaultBlocks[name] {
+					fieldVal = reflect.New(fieldTy).Elem() // encode the defaults of the absent block
+				}
+				if sample && !fieldVal.IsValid() {
+					// show the absent optional block, without adding it
+					dst.AppendNewline()
+					dst.AppendUnstructuredTokens(blockComment(name, field.Type, tags))
+					dst.AppendUnstructuredTokens(commentOut(sampleBlock(name, fieldTy)))
+					prevWasBlock = true
+					continue
+				}
 				if !fieldVal.IsValid() {
 					continue // ignore (field value is nil pointer)
 				}
 				if elemTy.Kind() == reflect.Ptr && fieldVal.IsNil() {
 					continue // ignore
 				}
-				block := EncodeAsBlock(fieldVal.Interface(), name)
+				block := encodeAsBlock(fieldVal.Interface(), name, sample)
 				if !prevWasBlock {
 					dst.AppendNewline()
 					prevWasBlock = true
 				}
+				if sample {
+					dst.AppendUnstructuredTokens(blockComment(name, field.Type, tags))
+				}
 				dst.AppendBlock(block)
 			}
 		}
 	}
 }
+
+// setAttributeValue sets the attribute to the value of the field, which is not a nil pointer
+func setAttributeValue(dst *hclwrite.Body, name string, fieldVal reflect.Value) {
+	dst.SetAttributeValue(name, attributeValue(fieldVal))
+}
+
+// attributeValue encodes the value of an attribute field, which is not a nil pointer unless its type has a handler, with
+// the TypeHandler of its type if it has one
+func attributeValue(fieldVal reflect.Value) cty.Value {
+	if handler, ok := typeHandler(fieldVal.Type()); ok {
+		return handler.encodeValue(fieldVal)
+	}
+
+	if fieldVal.Kind() == reflect.Ptr {
+		fieldVal = fieldVal.Elem()
+		if handler, ok := typeHandler(fieldVal.Type()); ok {
+			return handler.encodeValue(fieldVal)
+		}
+	}
+
+	valTy, err := gocty.ImpliedType(fieldVal.Interface())
+	if err != nil {
+		panic(fmt.Sprintf("cannot encode %T as HCL expression: %s", fieldVal.Interface(), err))
+	}
+
+	val, err := gocty.ToCtyValue(fieldVal.Interface(), valTy)
+	if err != nil {
+		// This should never happen, since we should always be able
+		// to decode into the implied type.
+		panic(fmt.Sprintf("failed to encode %T as %#v: %s", fieldVal.Interface(), valTy, err))
+	}
+
+	return val
+}
+
+// setDefaultAttribute sets the attribute to the default tag value, as an expression when it is one and otherwise as a
+// string like it is decoded
+func setDefaultAttribute(dst *hclwrite.Body, name, src string) {
+	if _, ok := parseDefault(name, src); ok {
+		if f, diags := hclwrite.ParseConfig([]byte(name+" = "+src), "", hcl.Pos{Line: 1, Column: 1}); !diags.HasErrors() {
+			if attr := f.Body().GetAttribute(name); attr != nil {
+				dst.SetAttributeRaw(name, attr.Expr().BuildTokens(nil))
+				return
+			}
+		}
+	}
+
+	dst.SetAttributeValue(name, cty.StringVal(src))
+}
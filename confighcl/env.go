@@ -0,0 +1,74 @@
+package confighcl
+
+import (
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// EnvVar is an environment variable that overrides a field of a configuration
+type EnvVar struct {
+	// Name of the environment variable, such as APP_HTTP_LISTEN_ADDR
+	Name string
+
+	// Path of attribute and block names to the field
+	Path []string
+
+	// Type of the value, primitive values are read as is while others are HCL expressions such as ["a", "b"]
+	Type string
+
+	// Description of the attribute from its description tag, if any
+	Description string
+}
+
+// EnvName returns the environment variable name of the parts, upper-cased and joined by underscores with any character
+// that is not a letter or digit replaced by an underscore
+func EnvName(parts ...string) string {
+	var nonEmpty []string
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || (!unicode.IsLetter(r) && !unicode.IsDigit(r)) {
+			return '_'
+		}
+		return unicode.ToUpper(r)
+	}, strings.Join(nonEmpty, "_"))
+}
+
+// EnvVars lists the environment variables DecodeEnv reads for the given value, which must be a struct or a pointer to
+// one with the struct tags defined in this package.
+func EnvVars(prefix string, val interface{}) []EnvVar {
+	fields := Fields(val)
+
+	vars := make([]EnvVar, len(fields))
+	for i, field := range fields {
+		vars[i] = EnvVar{
+			Name:        EnvName(append([]string{prefix}, field.Path...)...),
+			Path:        field.Path,
+			Type:        field.Type,
+			Description: field.Description,
+		}
+	}
+
+	return vars
+}
+
+// DecodeEnv overrides the fields of the given value, which must be a non-nil pointer to a struct, with the environment
+// variables listed by EnvVars as described by DecodeFields.
+func DecodeEnv(prefix string, ctx *hcl.EvalContext, val interface{}) hcl.Diagnostics {
+	var values []FieldValue
+	for _, field := range Fields(val) {
+		name := EnvName(append([]string{prefix}, field.Path...)...)
+		if src, ok := os.LookupEnv(name); ok {
+			values = append(values, FieldValue{Field: field, Name: name, Src: src})
+		}
+	}
+
+	return DecodeFields(values, ctx, val)
+}
@@ -0,0 +1,293 @@
+package confighcl
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/gocty"
+)
+
+// Field is an attribute of a configuration that can be set from a string, such as an environment variable or a command
+// line flag
+type Field struct {
+	// Path of attribute and block names to the field
+	Path []string
+
+	// Type of the value, primitive values are read as is while others are HCL expressions such as ["a", "b"]
+	Type string
+
+	// Description of the attribute from its description tag, if any
+	Description string
+
+	// index of the struct fields from the root value
+	index []int
+
+	// ty is the type of the field
+	ty reflect.Type
+}
+
+// Fields lists the fields of the given value, which must be a struct or a pointer to one with the struct tags defined
+// in this package.
+//
+// Attributes and single blocks without labels are included, blocks decoded into slices or with labels and attributes
+// decoded into hcl.Expression or hcl.Attribute values cannot be set from a string.
+func Fields(val interface{}) []Field {
+	ty := reflect.TypeOf(val)
+	if ty.Kind() == reflect.Ptr {
+		ty = ty.Elem()
+	}
+	if ty.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("given value must be struct, not %T", val))
+	}
+
+	var fields []Field
+	walkFields(ty, nil, nil, map[reflect.Type]bool{}, func(path []string, index []int, fieldTy reflect.Type, description string) {
+		fields = append(fields, Field{Path: path, Type: fieldTypeName(fieldTy), Description: description, index: index, ty: fieldTy})
+	})
+
+	return fields
+}
+
+// FieldValue is a source string setting a field, named by its source such as an environment variable or a command line
+// flag
+type FieldValue struct {
+	Field Field
+	Name  string
+	Src   string
+}
+
+// DecodeField sets the field of the given value, which must be a non-nil pointer to the struct the field was listed
+// from, with the source string as described by DecodeFields.
+func DecodeField(field Field, name, src string, ctx *hcl.EvalContext, val interface{}) hcl.Diagnostics {
+	return DecodeFields([]FieldValue{{Field: field, Name: name, Src: src}}, ctx, val)
+}
+
+// DecodeFields sets the fields of the given value, which must be a non-nil pointer to the struct the fields were listed
+// from, with their source strings in order so later values of a field override earlier ones.
+//
+// Primitive values, including durations, are converted from the source string and all other values are parsed as HCL
+// expressions and evaluated with the given EvalContext. The value is checked by the validate tag of the field, and the
+// name identifies the source in diagnostics.
+//
+// Nil pointers to blocks are allocated when any of their fields are set, decoding the block like DecodeBody from a body
+// of all the fields set within it, so the defaults of the block apply and its required attributes must be set.
+func DecodeFields(values []FieldValue, ctx *hcl.EvalContext, val interface{}) hcl.Diagnostics {
+	rv := reflect.ValueOf(val)
+	if rv.Kind() != reflect.Ptr {
+		panic(fmt.Sprintf("target value must be a pointer, not %s", rv.Type().String()))
+	}
+
+	var diags hcl.Diagnostics
+	decoded := make([]bool, len(values))
+	for i, fv := range values {
+		if decoded[i] {
+			continue
+		}
+
+		parent, fieldV, depth := lookupField(rv.Elem(), fv.Field.index)
+		if depth < len(fv.Field.index) {
+			var block []FieldValue
+			for j := i; j < len(values); j++ {
+				if !decoded[j] && hasIndexPrefix(values[j].Field.index, fv.Field.index[:depth]) {
+					block = append(block, values[j])
+					decoded[j] = true
+				}
+			}
+
+			diags = append(diags, decodeFieldsBlock(block, depth, ctx, fieldV)...)
+			continue
+		}
+
+		expr, rng, exprDiags := fv.expression()
+		if exprDiags.HasErrors() {
+			diags = append(diags, exprDiags...)
+			continue
+		}
+
+		name := fv.Field.Path[len(fv.Field.Path)-1]
+		fieldDiags := DecodeExpression(expr, ctx, fieldV.Addr().Interface())
+		if !fieldDiags.HasErrors() {
+			fieldDiags = append(fieldDiags, validateAttribute(parent, getFieldTags(parent.Type()), name, rng.Ptr(), rng)...)
+		}
+		diags = append(diags, fieldDiags...)
+	}
+
+	return diags
+}
+
+// decodeFieldsBlock allocates the nil pointer to a block by decoding the body of the fields set within it, the path of
+// the fields within the block starts at depth
+func decodeFieldsBlock(values []FieldValue, depth int, ctx *hcl.EvalContext, ptr reflect.Value) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	var body *hclsyntax.Body
+	for _, fv := range values {
+		expr, rng, exprDiags := fv.expression()
+		if diags = append(diags, exprDiags...); exprDiags.HasErrors() {
+			continue
+		}
+
+		if body == nil {
+			body = &hclsyntax.Body{SrcRange: rng, EndRange: rng}
+		}
+
+		b := body
+		path := fv.Field.Path[depth:]
+		for _, blockType := range path[:len(path)-1] {
+			b = childBody(b, blockType, rng)
+		}
+
+		if b.Attributes == nil {
+			b.Attributes = make(hclsyntax.Attributes)
+		}
+		name := path[len(path)-1]
+		b.Attributes[name] = &hclsyntax.Attribute{Name: name, Expr: expr, SrcRange: rng, NameRange: rng}
+	}
+
+	if diags.HasErrors() {
+		return diags
+	}
+
+	block := reflect.New(ptr.Type().Elem())
+	if diags = append(diags, decodeBodyToValue(body, ctx, block.Elem())...); !diags.HasErrors() {
+		ptr.Set(block)
+	}
+
+	return diags
+}
+
+// childBody returns the body of the block of the type within the body, adding the block when there is none
+func childBody(body *hclsyntax.Body, blockType string, rng hcl.Range) *hclsyntax.Body {
+	for _, block := range body.Blocks {
+		if block.Type == blockType {
+			return block.Body
+		}
+	}
+
+	block := &hclsyntax.Block{
+		Type:      blockType,
+		Body:      &hclsyntax.Body{SrcRange: rng, EndRange: rng},
+		TypeRange: rng,
+	}
+	body.Blocks = append(body.Blocks, block)
+
+	return block.Body
+}
+
+// expression returns the expression of the source string and its range, the string itself for primitive fields and
+// otherwise the parsed HCL expression
+func (fv FieldValue) expression() (hclsyntax.Expression, hcl.Range, hcl.Diagnostics) {
+	rng := hcl.Range{
+		Filename: fv.Name,
+		Start:    hcl.Pos{Line: 1, Column: 1, Byte: 0},
+		End:      hcl.Pos{Line: 1, Column: len(fv.Src) + 1, Byte: len(fv.Src)},
+	}
+
+	if primitiveField(fv.Field.ty) {
+		return &hclsyntax.LiteralValueExpr{Val: cty.StringVal(fv.Src), SrcRange: rng}, rng, nil
+	}
+
+	expr, diags := hclsyntax.ParseExpression([]byte(fv.Src), fv.Name, rng.Start)
+	return expr, rng, diags
+}
+
+// lookupField returns the field at the index and the struct holding it, or the nil pointer to a block on the way with
+// the number of indexes leading to it
+func lookupField(v reflect.Value, index []int) (parent, field reflect.Value, depth int) {
+	for i, idx := range index {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return parent, v, i
+			}
+			v = v.Elem()
+		}
+		parent, v = v, v.Field(idx)
+	}
+
+	return parent, v, len(index)
+}
+
+// hasIndexPrefix returns whether the index starts with the prefix
+func hasIndexPrefix(index, prefix []int) bool {
+	if len(index) < len(prefix) {
+		return false
+	}
+
+	for i := range prefix {
+		if index[i] != prefix[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// walkFields calls fn for each attribute of the struct type in name order, visiting is the set of block types on the
+// current path to stop recursive types
+func walkFields(ty reflect.Type, path []string, index []int, visiting map[reflect.Type]bool, fn func([]string, []int, reflect.Type, string)) {
+	visiting[ty] = true
+	defer delete(visiting, ty)
+
+	schema, _ := ImpliedBodySchema(reflect.New(ty).Interface())
+	tags := getFieldTags(ty)
+
+	for _, attrS := range schema.Attributes {
+		idx := tags.Attributes[attrS.Name]
+		field := ty.Field(idx)
+		if exprType.AssignableTo(field.Type) || attrType.AssignableTo(field.Type) {
+			continue
+		}
+
+		fn(appendPath(path, attrS.Name), appendIndex(index, idx), field.Type, tags.Descriptions[attrS.Name])
+	}
+
+	for _, blockS := range schema.Blocks {
+		idx := tags.Blocks[blockS.Type]
+		fty := ty.Field(idx).Type
+		if fty.Kind() == reflect.Ptr {
+			fty = fty.Elem()
+		}
+
+		if len(blockS.LabelNames) > 0 || fty.Kind() != reflect.Struct || visiting[fty] {
+			continue
+		}
+
+		walkFields(fty, appendPath(path, blockS.Type), appendIndex(index, idx), visiting, fn)
+	}
+}
+
+// primitiveField returns whether the values of the type are converted from strings rather than parsed as expressions
+func primitiveField(ty reflect.Type) bool {
+	if handler, ok := fieldHandler(ty); ok {
+		return handler.Type.IsPrimitiveType()
+	}
+
+	ctyTy, err := gocty.ImpliedType(reflect.New(ty).Interface())
+	return err == nil && ctyTy.IsPrimitiveType()
+}
+
+// fieldTypeName returns the name of the type used in listings
+func fieldTypeName(ty reflect.Type) string {
+	if handler, ok := fieldHandler(ty); ok {
+		return handler.typeName()
+	}
+
+	ty = indirectType(ty)
+
+	ctyTy, err := gocty.ImpliedType(reflect.New(ty).Interface())
+	if err != nil {
+		return ty.String()
+	}
+
+	return ctyTy.FriendlyName()
+}
+
+func appendPath(path []string, name string) []string {
+	return append(path[:len(path):len(path)], name)
+}
+
+func appendIndex(index []int, idx int) []int {
+	return append(index[:len(index):len(index)], idx)
+}
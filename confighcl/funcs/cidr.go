@@ -0,0 +1,133 @@
+package funcs
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// CIDRSubnetFunc returns the subnet of a CIDR prefix extended by a number of bits, numbered by the value of those bits,
+// such as 10.1.2.0/24 for cidrsubnet("10.1.0.0/16", 8, 2)
+var CIDRSubnetFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "prefix",
+			Type: cty.String,
+		},
+		{
+			Name: "newbits",
+			Type: cty.Number,
+		},
+		{
+			Name: "netnum",
+			Type: cty.Number,
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		prefix, err := parsePrefix(args[0])
+		if err != nil {
+			return cty.UnknownVal(cty.String), err
+		}
+
+		newbits, err := integerArg(args, 1)
+		if err != nil {
+			return cty.UnknownVal(cty.String), err
+		}
+		netnum, err := integerArg(args, 2)
+		if err != nil {
+			return cty.UnknownVal(cty.String), err
+		}
+
+		bits := prefix.Bits() + int(newbits.Int64())
+		if !newbits.IsInt64() || newbits.Sign() < 0 || bits > prefix.Addr().BitLen() {
+			return cty.UnknownVal(cty.String), function.NewArgErrorf(1, "%s cannot be extended by %s bits", prefix, newbits)
+		}
+
+		hostBits := uint(prefix.Addr().BitLen() - bits)
+		if netnum.Sign() < 0 || netnum.BitLen() > bits-prefix.Bits() {
+			return cty.UnknownVal(cty.String), function.NewArgErrorf(2, "%s bits extending %s cannot number network %s", newbits, prefix, netnum)
+		}
+
+		addr := addAddr(prefix.Addr(), new(big.Int).Lsh(netnum, hostBits))
+		return cty.StringVal(netip.PrefixFrom(addr, bits).String()), nil
+	},
+})
+
+// CIDRHostFunc returns the address of a host of a CIDR prefix by its number, from the end of the prefix when negative,
+// such as 10.1.2.5 for cidrhost("10.1.2.0/24", 5)
+var CIDRHostFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "prefix",
+			Type: cty.String,
+		},
+		{
+			Name: "hostnum",
+			Type: cty.Number,
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		prefix, err := parsePrefix(args[0])
+		if err != nil {
+			return cty.UnknownVal(cty.String), err
+		}
+
+		hostnum, err := integerArg(args, 1)
+		if err != nil {
+			return cty.UnknownVal(cty.String), err
+		}
+
+		hosts := new(big.Int).Lsh(big.NewInt(1), uint(prefix.Addr().BitLen()-prefix.Bits()))
+		num := new(big.Int).Set(hostnum)
+		if num.Sign() < 0 {
+			num.Add(num, hosts)
+		}
+		if num.Sign() < 0 || num.Cmp(hosts) >= 0 {
+			return cty.UnknownVal(cty.String), function.NewArgErrorf(1, "%s has no host number %s", prefix, hostnum)
+		}
+
+		return cty.StringVal(addAddr(prefix.Addr(), num).String()), nil
+	},
+})
+
+// parsePrefix parses the CIDR prefix argument, masked to its network address
+func parsePrefix(arg cty.Value) (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(arg.AsString())
+	if err != nil {
+		return netip.Prefix{}, function.NewArgErrorf(0, "%q is not a CIDR prefix such as 10.0.0.0/8", arg.AsString())
+	}
+
+	return prefix.Masked(), nil
+}
+
+// integerArg returns the number argument at the index, which must be an integer
+func integerArg(args []cty.Value, idx int) (*big.Int, error) {
+	f := args[idx].AsBigFloat()
+	if !f.IsInt() {
+		return nil, function.NewArgErrorf(idx, "%s is not an integer", f.Text('f', -1))
+	}
+
+	i, _ := f.Int(nil)
+	return i, nil
+}
+
+// addAddr returns the address offset by the number
+func addAddr(addr netip.Addr, num *big.Int) netip.Addr {
+	sum := new(big.Int).SetBytes(addr.AsSlice())
+	sum.Add(sum, num)
+
+	b := make([]byte, addr.BitLen()/8)
+	sum.FillBytes(b)
+
+	result, ok := netip.AddrFromSlice(b)
+	if !ok {
+		panic(fmt.Sprintf("invalid address of %d bytes", len(b)))
+	}
+
+	return result
+}
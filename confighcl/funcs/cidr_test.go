@@ -0,0 +1,23 @@
+package funcs
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestCIDRFuncs(t *testing.T) {
+	testFuncs(t, Stdlib(), []funcTest{
+		{Expr: `cidrsubnet("10.1.0.0/16", 8, 2)`, Expected: cty.StringVal("10.1.2.0/24")},
+		{Expr: `cidrsubnet("10.1.7.9/16", 8, 255)`, Expected: cty.StringVal("10.1.255.0/24")},
+		{Expr: `cidrsubnet("fd00::/48", 16, 258)`, Expected: cty.StringVal("fd00:0:0:102::/64")},
+		{Expr: `cidrsubnet("10.1.0.0/16", 8, 256)`, Error: "8 bits extending 10.1.0.0/16 cannot number network 256"},
+		{Expr: `cidrsubnet("10.1.0.0/16", 17, 0)`, Error: "10.1.0.0/16 cannot be extended by 17 bits"},
+		{Expr: `cidrsubnet("10.1.0.0/16", 1.5, 0)`, Error: "1.5 is not an integer"},
+		{Expr: `cidrsubnet("10.1.0.0", 8, 0)`, Error: `"10.1.0.0" is not a CIDR prefix such as 10.0.0.0/8`},
+		{Expr: `cidrhost("10.1.2.0/24", 5)`, Expected: cty.StringVal("10.1.2.5")},
+		{Expr: `cidrhost("10.1.2.0/24", -1)`, Expected: cty.StringVal("10.1.2.255")},
+		{Expr: `cidrhost("fd00::/64", 16)`, Expected: cty.StringVal("fd00::10")},
+		{Expr: `cidrhost("10.1.2.0/24", 256)`, Error: "10.1.2.0/24 has no host number 256"},
+	})
+}
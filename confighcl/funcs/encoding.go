@@ -0,0 +1,70 @@
+package funcs
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"unicode/utf8"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// Base64EncodeFunc encodes a string with standard base64 encoding
+var Base64EncodeFunc = stringFunc("str", func(s string) (string, error) {
+	return base64.StdEncoding.EncodeToString([]byte(s)), nil
+})
+
+// Base64DecodeFunc decodes a string of standard base64 encoding, which must decode to UTF-8 text
+var Base64DecodeFunc = stringFunc("str", func(s string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode base64 data: %w", err)
+	}
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("the decoded base64 data is not valid UTF-8")
+	}
+
+	return string(data), nil
+})
+
+// URLEncodeFunc escapes a string so it can be placed in a URL query, such as a+b%2Fc for a b/c
+var URLEncodeFunc = stringFunc("str", func(s string) (string, error) {
+	return url.QueryEscape(s), nil
+})
+
+// SHA256Func returns the hexadecimal SHA-256 hash of a string
+var SHA256Func = stringFunc("str", func(s string) (string, error) {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:]), nil
+})
+
+// MD5Func returns the hexadecimal MD5 hash of a string
+var MD5Func = stringFunc("str", func(s string) (string, error) {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:]), nil
+})
+
+// stringFunc returns a function of a string parameter returning a string
+func stringFunc(param string, impl func(string) (string, error)) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: param,
+				Type: cty.String,
+			},
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			s, err := impl(args[0].AsString())
+			if err != nil {
+				return cty.UnknownVal(cty.String), err
+			}
+
+			return cty.StringVal(s), nil
+		},
+	})
+}
@@ -0,0 +1,19 @@
+package funcs
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestEncodingFuncs(t *testing.T) {
+	testFuncs(t, Stdlib(), []funcTest{
+		{Expr: `base64encode("hello")`, Expected: cty.StringVal("aGVsbG8=")},
+		{Expr: `base64decode("aGVsbG8=")`, Expected: cty.StringVal("hello")},
+		{Expr: `base64decode("!")`, Error: "failed to decode base64 data"},
+		{Expr: `base64decode("//4=")`, Error: "the decoded base64 data is not valid UTF-8"},
+		{Expr: `sha256("hello")`, Expected: cty.StringVal("2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824")},
+		{Expr: `md5("hello")`, Expected: cty.StringVal("5d41402abc4b2a76b9719d911017c592")},
+		{Expr: `urlencode("a b/c?")`, Expected: cty.StringVal("a+b%2Fc%3F")},
+	})
+}
@@ -0,0 +1,258 @@
+package funcs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// Filesystem are the functions reading files, resolving relative paths from the base directory, such as the directory
+// of the configuration file. Templates read by templatefile can call the functions of Stdlib and these functions, but
+// not templatefile itself.
+func Filesystem(baseDir string) map[string]function.Function {
+	funcs := map[string]function.Function{
+		"file":       MakeFileFunc(baseDir),
+		"fileexists": MakeFileExistsFunc(baseDir),
+		"fileset":    MakeFileSetFunc(baseDir),
+	}
+
+	templateFuncs := Stdlib()
+	for name, f := range funcs {
+		templateFuncs[name] = f
+	}
+	funcs["templatefile"] = MakeTemplateFileFunc(baseDir, templateFuncs)
+
+	return funcs
+}
+
+// MakeFileFunc returns the file function, reading a file of UTF-8 text relative to the base directory
+func MakeFileFunc(baseDir string) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "path",
+				Type: cty.String,
+			},
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			src, err := readFile(baseDir, args[0].AsString())
+			if err != nil {
+				return cty.UnknownVal(cty.String), err
+			}
+
+			return cty.StringVal(src), nil
+		},
+	})
+}
+
+// MakeFileExistsFunc returns the fileexists function, whether a file exists relative to the base directory
+func MakeFileExistsFunc(baseDir string) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "path",
+				Type: cty.String,
+			},
+		},
+		Type: function.StaticReturnType(cty.Bool),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			name := args[0].AsString()
+
+			info, err := os.Stat(resolvePath(baseDir, name))
+			if errors.Is(err, fs.ErrNotExist) {
+				return cty.False, nil
+			}
+			if err != nil {
+				return cty.UnknownVal(cty.Bool), fmt.Errorf("failed to stat %s: %w", name, err)
+			}
+			if !info.Mode().IsRegular() {
+				return cty.UnknownVal(cty.Bool), fmt.Errorf("%s is not a regular file", name)
+			}
+
+			return cty.True, nil
+		},
+	})
+}
+
+// MakeFileSetFunc returns the fileset function, the set of the paths of the files in a directory relative to the base
+// directory matching a pattern, such as fileset("templates", "**/*.tmpl"). Patterns match paths relative to the
+// directory separated by slashes with the syntax of path.Match, and ** matches any number of directories.
+func MakeFileSetFunc(baseDir string) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "path",
+				Type: cty.String,
+			},
+			{
+				Name: "pattern",
+				Type: cty.String,
+			},
+		},
+		Type: function.StaticReturnType(cty.Set(cty.String)),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			dir := resolvePath(baseDir, args[0].AsString())
+
+			pattern := strings.Split(args[1].AsString(), "/")
+			for _, segment := range pattern {
+				if _, err := path.Match(segment, ""); err != nil {
+					return cty.UnknownVal(retType), function.NewArgErrorf(1, "invalid pattern %q: %s", args[1].AsString(), err)
+				}
+			}
+
+			var matches []cty.Value
+			err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+				if err != nil || !d.Type().IsRegular() {
+					return err
+				}
+
+				rel, err := filepath.Rel(dir, name)
+				if err != nil {
+					return err
+				}
+
+				if rel = filepath.ToSlash(rel); matchSegments(pattern, strings.Split(rel, "/")) {
+					matches = append(matches, cty.StringVal(rel))
+				}
+				return nil
+			})
+			if err != nil {
+				return cty.UnknownVal(retType), fmt.Errorf("failed to list %s: %w", args[0].AsString(), err)
+			}
+
+			if len(matches) == 0 {
+				return cty.SetValEmpty(cty.String), nil
+			}
+			return cty.SetVal(matches), nil
+		},
+	})
+}
+
+// MakeTemplateFileFunc returns the templatefile function, rendering a template file relative to the base directory
+// with the variables of an object or map, such as templatefile("motd.tmpl", { name = "world" }). Templates can call
+// the given functions.
+func MakeTemplateFileFunc(baseDir string, funcs map[string]function.Function) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "path",
+				Type: cty.String,
+			},
+			{
+				Name: "vars",
+				Type: cty.DynamicPseudoType,
+			},
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			name := args[0].AsString()
+
+			vars := args[1]
+			if !vars.Type().IsObjectType() && !vars.Type().IsMapType() {
+				return cty.UnknownVal(cty.String), function.NewArgErrorf(1, "template variables must be an object or a map, not %s", vars.Type().FriendlyName())
+			}
+			if vars.IsNull() || !vars.IsWhollyKnown() {
+				return cty.UnknownVal(cty.String), function.NewArgErrorf(1, "template variables must be known")
+			}
+
+			ctx := &hcl.EvalContext{Variables: map[string]cty.Value{}, Functions: funcs}
+			for it := vars.ElementIterator(); it.Next(); {
+				k, v := it.Element()
+				if !hclsyntax.ValidIdentifier(k.AsString()) {
+					return cty.UnknownVal(cty.String), function.NewArgErrorf(1, "template variable %q is not an identifier", k.AsString())
+				}
+				ctx.Variables[k.AsString()] = v
+			}
+
+			src, err := readFile(baseDir, name)
+			if err != nil {
+				return cty.UnknownVal(cty.String), err
+			}
+
+			expr, diags := hclsyntax.ParseTemplate([]byte(src), name, hcl.Pos{Line: 1, Column: 1, Byte: 0})
+			if diags.HasErrors() {
+				return cty.UnknownVal(cty.String), diags
+			}
+
+			for _, traversal := range expr.Variables() {
+				if _, ok := ctx.Variables[traversal.RootName()]; !ok {
+					rng := traversal.SourceRange()
+					return cty.UnknownVal(cty.String), fmt.Errorf("%s: template variable %q is not set", rng.String(), traversal.RootName())
+				}
+			}
+
+			val, diags := expr.Value(ctx)
+			if diags.HasErrors() {
+				return cty.UnknownVal(cty.String), diags
+			}
+			str, err := convert.Convert(val, cty.String)
+			if err != nil {
+				return cty.UnknownVal(cty.String), fmt.Errorf("template %s must produce a string, not %s", name, val.Type().FriendlyName())
+			}
+
+			return str, nil
+		},
+	})
+}
+
+// resolvePath returns the path relative to the base directory unless it is absolute
+func resolvePath(baseDir, name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+
+	return filepath.Join(baseDir, name)
+}
+
+// readFile reads the file of UTF-8 text at the path relative to the base directory
+func readFile(baseDir, name string) (string, error) {
+	data, err := os.ReadFile(resolvePath(baseDir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("no file exists at %s", name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("the contents of %s are not valid UTF-8", name)
+	}
+
+	return string(data), nil
+}
+
+// matchSegments returns whether the segments of a path match the segments of a pattern, where ** matches any number of
+// segments
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(name) == 0 {
+		return false
+	}
+
+	ok, _ := path.Match(pattern[0], name[0])
+	return ok && matchSegments(pattern[1:], name[1:])
+}
@@ -0,0 +1,54 @@
+package funcs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestFileFuncs(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"motd.tmpl":       "Hello ${name}! ${upper(greeting)} %{ for h in hosts }${h},%{ endfor }",
+		"count.tmpl":      "${count}",
+		"include.tmpl":    `${file("data/a.txt")}`,
+		"data/a.txt":      "a\n",
+		"data/sub/b.txt":  "b\n",
+		"data/sub/c.json": "{}",
+		"binary.bin":      "\xff\xfe",
+	} {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	funcs := Stdlib()
+	for name, f := range Filesystem(dir) {
+		funcs[name] = f
+	}
+
+	testFuncs(t, funcs, []funcTest{
+		{Expr: `file("data/a.txt")`, Expected: cty.StringVal("a\n")},
+		{Expr: `file("` + filepath.ToSlash(filepath.Join(dir, "data", "a.txt")) + `")`, Expected: cty.StringVal("a\n")},
+		{Expr: `file("missing.txt")`, Error: "no file exists at missing.txt"},
+		{Expr: `file("binary.bin")`, Error: "the contents of binary.bin are not valid UTF-8"},
+		{Expr: `fileexists("data/a.txt")`, Expected: cty.True},
+		{Expr: `fileexists("missing.txt")`, Expected: cty.False},
+		{Expr: `fileexists("data")`, Error: "data is not a regular file"},
+		{Expr: `fileset("data", "**/*.txt")`, Expected: cty.SetVal([]cty.Value{cty.StringVal("a.txt"), cty.StringVal("sub/b.txt")})},
+		{Expr: `fileset("data", "sub/*")`, Expected: cty.SetVal([]cty.Value{cty.StringVal("sub/b.txt"), cty.StringVal("sub/c.json")})},
+		{Expr: `fileset("data", "*.md")`, Expected: cty.SetValEmpty(cty.String)},
+		{Expr: `fileset("data", "[")`, Error: "invalid pattern"},
+		{Expr: `templatefile("motd.tmpl", { name = "world", greeting = "hi", hosts = ["a", "b"] })`, Expected: cty.StringVal("Hello world! HI a,b,")},
+		{Expr: `templatefile("count.tmpl", { count = 3 })`, Expected: cty.StringVal("3")},
+		{Expr: `templatefile("include.tmpl", {})`, Expected: cty.StringVal("a\n")},
+		{Expr: `templatefile("motd.tmpl", { name = "world" })`, Error: `template variable "greeting" is not set`},
+		{Expr: `templatefile("motd.tmpl", "world")`, Error: "template variables must be an object or a map, not string"},
+	})
+}
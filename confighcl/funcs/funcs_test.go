@@ -0,0 +1,47 @@
+package funcs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// funcTest is an expression calling functions and its expected value, or the expected part of its error
+type funcTest struct {
+	Expr     string
+	Expected cty.Value
+	Error    string
+}
+
+// testFuncs evaluates the expressions of the tests with the functions
+func testFuncs(t *testing.T, funcs map[string]function.Function, tests []funcTest) {
+	t.Helper()
+
+	evalContext := &hcl.EvalContext{Functions: funcs}
+	for _, test := range tests {
+		t.Run(test.Expr, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(test.Expr), "test.hcl", hcl.Pos{Line: 1, Column: 1})
+			if diags.HasErrors() {
+				t.Fatal(diags.Error())
+			}
+
+			val, diags := expr.Value(evalContext)
+			if test.Error != "" {
+				if !diags.HasErrors() || !strings.Contains(diags.Error(), test.Error) {
+					t.Errorf("unexpected error: expected %q; got %v", test.Error, diags)
+				}
+				return
+			}
+
+			if diags.HasErrors() {
+				t.Errorf("unexpected error: %v", diags)
+			} else if !val.RawEquals(test.Expected) {
+				t.Errorf("unexpected value: expected %#v; got %#v", test.Expected, val)
+			}
+		})
+	}
+}
@@ -10,8 +10,12 @@ import (
 func Stdlib() map[string]function.Function {
 	return map[string]function.Function{
 		"abs":             stdlib.AbsoluteFunc,
+		"base64decode":    Base64DecodeFunc,
+		"base64encode":    Base64EncodeFunc,
 		"ceil":            stdlib.CeilFunc,
 		"chomp":           stdlib.ChompFunc,
+		"cidrhost":        CIDRHostFunc,
+		"cidrsubnet":      CIDRSubnetFunc,
 		"coalescelist":    stdlib.CoalesceListFunc,
 		"compact":         stdlib.CompactFunc,
 		"concat":          stdlib.ConcatFunc,
@@ -34,6 +38,7 @@ func Stdlib() map[string]function.Function {
 		"log":             stdlib.LogFunc,
 		"lower":           stdlib.LowerFunc,
 		"max":             stdlib.MaxFunc,
+		"md5":             MD5Func,
 		"merge":           stdlib.MergeFunc,
 		"min":             stdlib.MinFunc,
 		"parseint":        stdlib.ParseIntFunc,
@@ -47,6 +52,7 @@ func Stdlib() map[string]function.Function {
 		"setproduct":      stdlib.SetProductFunc,
 		"setsubtract":     stdlib.SetSubtractFunc,
 		"setunion":        stdlib.SetUnionFunc,
+		"sha256":          SHA256Func,
 		"signum":          stdlib.SignumFunc,
 		"slice":           stdlib.SliceFunc,
 		"sort":            stdlib.SortFunc,
@@ -60,9 +66,18 @@ func Stdlib() map[string]function.Function {
 		"trimspace":       stdlib.TrimSpaceFunc,
 		"trimsuffix":      stdlib.TrimSuffixFunc,
 		"upper":           stdlib.UpperFunc,
+		"urlencode":       URLEncodeFunc,
+		"uuid":            UUIDFunc,
+		"uuidv5":          UUIDv5Func,
 		"values":          stdlib.ValuesFunc,
 		"yamldecode":      ctyyaml.YAMLDecodeFunc,
 		"yamlencode":      ctyyaml.YAMLEncodeFunc,
 		"zipmap":          stdlib.ZipmapFunc,
 	}
 }
+
+// Impure returns the names of the functions of Stdlib that return a different value every time they are called, such
+// as uuid, which cannot be used where a value must be the same every time it is evaluated.
+func Impure() []string {
+	return []string{"uuid"}
+}
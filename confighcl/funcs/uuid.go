@@ -0,0 +1,79 @@
+package funcs
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// uuidNamespaces are the namespaces of name-based UUIDs defined by RFC 4122
+var uuidNamespaces = map[string]string{
+	"dns":  "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+	"url":  "6ba7b811-9dad-11d1-80b4-00c04fd430c8",
+	"oid":  "6ba7b812-9dad-11d1-80b4-00c04fd430c8",
+	"x500": "6ba7b814-9dad-11d1-80b4-00c04fd430c8",
+}
+
+// UUIDFunc returns a random version 4 UUID. It is impure, the UUID differs on every call and every time the configuration
+// is decoded, see UUIDv5Func for UUIDs that do not.
+var UUIDFunc = function.New(&function.Spec{
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		var uuid [16]byte
+		if _, err := rand.Read(uuid[:]); err != nil {
+			return cty.UnknownVal(cty.String), fmt.Errorf("failed to generate UUID: %w", err)
+		}
+
+		return cty.StringVal(formatUUID(uuid, 4)), nil
+	},
+})
+
+// UUIDv5Func returns the version 5 UUID of a name in a namespace, which is dns, url, oid, x500 or a UUID, so the same
+// name always has the same UUID
+var UUIDv5Func = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "namespace",
+			Type: cty.String,
+		},
+		{
+			Name: "name",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		namespace := args[0].AsString()
+		if uuid, ok := uuidNamespaces[namespace]; ok {
+			namespace = uuid
+		}
+
+		ns, err := hex.DecodeString(strings.ReplaceAll(namespace, "-", ""))
+		if err != nil || len(ns) != 16 || len(namespace) != 36 {
+			return cty.UnknownVal(cty.String), function.NewArgErrorf(0, "namespace %q is not dns, url, oid, x500 or a UUID", args[0].AsString())
+		}
+
+		h := sha1.New()
+		h.Write(ns)
+		h.Write([]byte(args[1].AsString()))
+
+		var uuid [16]byte
+		copy(uuid[:], h.Sum(nil))
+
+		return cty.StringVal(formatUUID(uuid, 5)), nil
+	},
+})
+
+// formatUUID sets the version and RFC 4122 variant bits of the UUID and formats it
+func formatUUID(uuid [16]byte, version byte) string {
+	uuid[6] = uuid[6]&0x0f | version<<4
+	uuid[8] = uuid[8]&0x3f | 0x80
+
+	s := hex.EncodeToString(uuid[:])
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
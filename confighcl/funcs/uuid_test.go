@@ -0,0 +1,32 @@
+package funcs
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestUUIDFuncs(t *testing.T) {
+	testFuncs(t, Stdlib(), []funcTest{
+		{Expr: `uuidv5("dns", "example.com")`, Expected: cty.StringVal("cfbff0d1-9375-5685-968c-48ce8b15ae17")},
+		{Expr: `uuidv5("6ba7b810-9dad-11d1-80b4-00c04fd430c8", "example.com")`, Expected: cty.StringVal("cfbff0d1-9375-5685-968c-48ce8b15ae17")},
+		{Expr: `uuidv5("example", "example.com")`, Error: `namespace "example" is not dns, url, oid, x500 or a UUID`},
+	})
+
+	t.Run("uuid", func(t *testing.T) {
+		uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+		expr, _ := hclsyntax.ParseExpression([]byte(`[uuid(), uuid()]`), "test.hcl", hcl.Pos{Line: 1, Column: 1})
+		val, diags := expr.Value(&hcl.EvalContext{Functions: Stdlib()})
+		if diags.HasErrors() {
+			t.Fatal(diags.Error())
+		}
+
+		first, second := val.Index(cty.NumberIntVal(0)).AsString(), val.Index(cty.NumberIntVal(1)).AsString()
+		if !uuid.MatchString(first) || !uuid.MatchString(second) || first == second {
+			t.Errorf("unexpected UUIDs %s and %s", first, second)
+		}
+	})
+}
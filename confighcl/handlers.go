@@ -0,0 +1,301 @@
+package confighcl
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// TypeHandler decodes and encodes the values of a Go type that gocty cannot convert, such as time.Duration
+type TypeHandler struct {
+	// Type of the configuration values, such as cty.String for durations
+	Type cty.Type
+
+	// Name of the type in listings such as command line usage, the friendly name of Type when empty
+	Name string
+
+	// Decode converts a known, non-null configuration value of Type into a value of the registered type
+	Decode func(cty.Value) (interface{}, error)
+
+	// Encode converts a value of the registered type into a configuration value of Type
+	Encode func(interface{}) (cty.Value, error)
+}
+
+// typeHandlers are the registered handlers by type
+var typeHandlers = struct {
+	sync.RWMutex
+	m map[reflect.Type]TypeHandler
+}{m: map[reflect.Type]TypeHandler{}}
+
+// RegisterType sets the handler decoding and encoding the attributes of the given type, and pointers to it, replacing
+// any handler of the type. It is usually called from an init function, and panics when the handler is incomplete.
+//
+// Pointer types such as *url.URL can be registered themselves, their handlers must then encode nil pointers. Slices of
+// handled types, and maps of them with string keys, are lists and maps of the values of the handler.
+//
+// Types without a handler that implement encoding.TextUnmarshaler are decoded from strings, and encoded with
+// encoding.TextMarshaler when they implement it.
+func RegisterType(ty reflect.Type, handler TypeHandler) {
+	if handler.Type == cty.NilType || handler.Decode == nil || handler.Encode == nil {
+		panic(fmt.Sprintf("incomplete handler of type %s: the type, decode and encode functions are required", ty))
+	}
+
+	typeHandlers.Lock()
+	defer typeHandlers.Unlock()
+
+	typeHandlers.m[ty] = handler
+}
+
+// typeHandler returns the handler of the type, registered or for types implementing encoding.TextUnmarshaler
+func typeHandler(ty reflect.Type) (TypeHandler, bool) {
+	typeHandlers.RLock()
+	handler, ok := typeHandlers.m[ty]
+	typeHandlers.RUnlock()
+
+	if ok || ty.Kind() == reflect.Ptr {
+		return handler, ok
+	}
+
+	if !reflect.PtrTo(ty).Implements(textUnmarshalerType) {
+		return collectionHandler(ty)
+	}
+
+	return TypeHandler{
+		Type: cty.String,
+		Decode: func(val cty.Value) (interface{}, error) {
+			v := reflect.New(ty)
+			if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(val.AsString())); err != nil {
+				return nil, err
+			}
+			return v.Elem().Interface(), nil
+		},
+		Encode: func(v interface{}) (cty.Value, error) {
+			rv := reflect.New(ty)
+			rv.Elem().Set(reflect.ValueOf(v))
+			if !rv.Type().Implements(textMarshalerType) {
+				return cty.StringVal(fmt.Sprint(v)), nil
+			}
+
+			text, err := rv.Interface().(encoding.TextMarshaler).MarshalText()
+			if err != nil {
+				return cty.NilVal, err
+			}
+			return cty.StringVal(string(text)), nil
+		},
+	}, true
+}
+
+// collectionHandler returns the handler of the slices, and maps with string keys, whose elements have a handler, such
+// as []netip.Prefix or map[string]*url.URL
+func collectionHandler(ty reflect.Type) (TypeHandler, bool) {
+	if ty.Kind() != reflect.Slice && (ty.Kind() != reflect.Map || ty.Key().Kind() != reflect.String) {
+		return TypeHandler{}, false
+	}
+
+	elemTy := ty.Elem()
+	elem, ok := fieldHandler(elemTy)
+	if !ok {
+		return TypeHandler{}, false
+	}
+
+	// elements that are pointers to a handled type, rather than a registered pointer type, are encoded dereferenced
+	_, direct := typeHandler(elemTy)
+
+	decodeElem := func(val cty.Value) (reflect.Value, error) {
+		if val.IsNull() {
+			return reflect.Zero(elemTy), nil
+		}
+
+		v, err := elem.Decode(val)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return handledValue(v, elemTy), nil
+	}
+	encodeElem := func(v reflect.Value) (cty.Value, error) {
+		if !direct && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return cty.NullVal(elem.Type), nil
+			}
+			v = v.Elem()
+		}
+
+		return elem.Encode(v.Interface())
+	}
+
+	if ty.Kind() == reflect.Slice {
+		return TypeHandler{
+			Type: cty.List(elem.Type),
+			Name: "list of " + elem.typeName(),
+			Decode: func(val cty.Value) (interface{}, error) {
+				s := reflect.MakeSlice(ty, 0, val.LengthInt())
+				for it := val.ElementIterator(); it.Next(); {
+					_, ev := it.Element()
+					v, err := decodeElem(ev)
+					if err != nil {
+						return nil, fmt.Errorf("element %d: %w", s.Len(), err)
+					}
+					s = reflect.Append(s, v)
+				}
+				return s.Interface(), nil
+			},
+			Encode: func(v interface{}) (cty.Value, error) {
+				rv := reflect.ValueOf(v)
+				if rv.IsNil() {
+					return cty.NullVal(cty.List(elem.Type)), nil
+				}
+				if rv.Len() == 0 {
+					return cty.ListValEmpty(elem.Type), nil
+				}
+
+				vals := make([]cty.Value, rv.Len())
+				for i := range vals {
+					val, err := encodeElem(rv.Index(i))
+					if err != nil {
+						return cty.NilVal, err
+					}
+					vals[i] = val
+				}
+				return cty.ListVal(vals), nil
+			},
+		}, true
+	}
+
+	return TypeHandler{
+		Type: cty.Map(elem.Type),
+		Name: "map of " + elem.typeName(),
+		Decode: func(val cty.Value) (interface{}, error) {
+			m := reflect.MakeMapWithSize(ty, val.LengthInt())
+			for it := val.ElementIterator(); it.Next(); {
+				key, ev := it.Element()
+				v, err := decodeElem(ev)
+				if err != nil {
+					return nil, fmt.Errorf("element %q: %w", key.AsString(), err)
+				}
+				m.SetMapIndex(reflect.ValueOf(key.AsString()).Convert(ty.Key()), v)
+			}
+			return m.Interface(), nil
+		},
+		Encode: func(v interface{}) (cty.Value, error) {
+			rv := reflect.ValueOf(v)
+			if rv.IsNil() {
+				return cty.NullVal(cty.Map(elem.Type)), nil
+			}
+			if rv.Len() == 0 {
+				return cty.MapValEmpty(elem.Type), nil
+			}
+
+			vals := make(map[string]cty.Value, rv.Len())
+			for it := rv.MapRange(); it.Next(); {
+				val, err := encodeElem(it.Value())
+				if err != nil {
+					return cty.NilVal, err
+				}
+				vals[it.Key().String()] = val
+			}
+			return cty.MapVal(vals), nil
+		},
+	}, true
+}
+
+// handledValue returns the value decoded by a handler as a value of the type, which is the handled type or a pointer to
+// one
+func handledValue(v interface{}, ty reflect.Type) reflect.Value {
+	rv := reflect.ValueOf(v)
+	if ty.Kind() == reflect.Ptr && !rv.Type().ConvertibleTo(ty) {
+		ptr := reflect.New(ty.Elem())
+		ptr.Elem().Set(rv.Convert(ty.Elem()))
+		return ptr
+	}
+
+	return rv.Convert(ty)
+}
+
+// fieldHandler returns the handler of the values of an attribute of the type, which is a handled type or a pointer to
+// one
+func fieldHandler(ty reflect.Type) (TypeHandler, bool) {
+	if handler, ok := typeHandler(ty); ok {
+		return handler, true
+	}
+
+	if ty.Kind() == reflect.Ptr {
+		return typeHandler(ty.Elem())
+	}
+
+	return TypeHandler{}, false
+}
+
+// typeName returns the name of the type of the handler used in listings
+func (h TypeHandler) typeName() string {
+	if h.Name != "" {
+		return h.Name
+	}
+
+	return h.Type.FriendlyName()
+}
+
+// decodeExpression decodes the value of the expression with the handler into the target, which is a value of the
+// handled type or a pointer to one, found by fieldHandler
+func (h TypeHandler) decodeExpression(expr hcl.Expression, ctx *hcl.EvalContext, target reflect.Value) hcl.Diagnostics {
+	srcVal, diags := expr.Value(ctx)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	srcVal, secretDiags := unmarkSecret(srcVal, target.Type(), expr)
+	if diags = append(diags, secretDiags...); diags.HasErrors() {
+		return diags
+	}
+
+	if srcVal.IsNull() {
+		target.Set(reflect.Zero(target.Type()))
+		return diags
+	}
+
+	srcVal, err := convert.Convert(srcVal, h.Type)
+	if err == nil && !srcVal.IsWhollyKnown() {
+		err = errors.New("the value must be known")
+	}
+	if err != nil {
+		return append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unsuitable value type",
+			Detail:   fmt.Sprintf("Unsuitable value: %s", err.Error()),
+			Subject:  expr.StartRange().Ptr(),
+			Context:  expr.Range().Ptr(),
+		})
+	}
+
+	v, err := h.Decode(srcVal)
+	if err != nil {
+		return append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("Failed to parse %s", h.typeName()),
+			Detail:   fmt.Sprintf("The %s is invalid: %s.", h.typeName(), err),
+			Subject:  expr.StartRange().Ptr(),
+			Context:  expr.Range().Ptr(),
+		})
+	}
+
+	target.Set(handledValue(v, target.Type()))
+
+	return diags
+}
+
+// encodeValue encodes the value of the handled type with the handler, panicking when it fails since the value is from
+// the calling program
+func (h TypeHandler) encodeValue(v reflect.Value) cty.Value {
+	val, err := h.Encode(v.Interface())
+	if err != nil {
+		panic(fmt.Sprintf("failed to encode %s: %s", v.Type(), err))
+	}
+
+	return val
+}
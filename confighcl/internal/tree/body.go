@@ -0,0 +1,279 @@
+package tree
+
+import (
+	"fmt"
+
+	"github.com/agext/levenshtein"
+	"github.com/hashicorp/hcl/v2"
+)
+
+// body is the hcl.Body of an object node
+type body struct {
+	val Node
+
+	// hiddenAttrs are treated as non-existing, for the remaining content of PartialContent
+	hiddenAttrs map[string]struct{}
+}
+
+func (b *body) Content(schema *hcl.BodySchema) (*hcl.BodyContent, hcl.Diagnostics) {
+	content, remain, diags := b.PartialContent(schema)
+
+	hiddenAttrs := remain.(*body).hiddenAttrs
+
+	var suggestions []string
+	for _, attrS := range schema.Attributes {
+		if _, ok := hiddenAttrs[attrS.Name]; !ok {
+			suggestions = append(suggestions, attrS.Name)
+		}
+	}
+	for _, blockS := range schema.Blocks {
+		suggestions = append(suggestions, blockS.Type)
+	}
+
+	attrs, attrDiags := collectAttrs(b.val, nil)
+	diags = append(diags, attrDiags...)
+
+	for _, attr := range attrs {
+		if _, ok := hiddenAttrs[attr.Name]; ok {
+			continue
+		}
+
+		suggestion := nameSuggestion(attr.Name, suggestions)
+		if suggestion != "" {
+			suggestion = fmt.Sprintf(" Did you mean %q?", suggestion)
+		}
+
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unsupported argument",
+			Detail:   fmt.Sprintf("No argument or block type is named %q.%s", attr.Name, suggestion),
+			Subject:  attr.NameRange.Ptr(),
+			Context:  attr.Range().Ptr(),
+		})
+	}
+
+	return content, diags
+}
+
+func (b *body) PartialContent(schema *hcl.BodySchema) (*hcl.BodyContent, hcl.Body, hcl.Diagnostics) {
+	attrs, diags := collectAttrs(b.val, nil)
+
+	usedNames := make(map[string]struct{}, len(b.hiddenAttrs))
+	for k := range b.hiddenAttrs {
+		usedNames[k] = struct{}{}
+	}
+
+	content := &hcl.BodyContent{
+		Attributes:       map[string]*hcl.Attribute{},
+		MissingItemRange: b.MissingItemRange(),
+	}
+
+	attrSchemas := map[string]hcl.AttributeSchema{}
+	blockSchemas := map[string]hcl.BlockHeaderSchema{}
+	for _, attrS := range schema.Attributes {
+		attrSchemas[attrS.Name] = attrS
+	}
+	for _, blockS := range schema.Blocks {
+		blockSchemas[blockS.Type] = blockS
+	}
+
+	for _, attr := range attrs {
+		if _, hidden := b.hiddenAttrs[attr.Name]; hidden {
+			continue
+		}
+
+		if _, defined := attrSchemas[attr.Name]; defined {
+			if existing, exists := content.Attributes[attr.Name]; exists {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate argument",
+					Detail:   fmt.Sprintf("The argument %q was already set at %s.", attr.Name, existing.Range),
+					Subject:  attr.NameRange.Ptr(),
+					Context:  attr.Range().Ptr(),
+				})
+				continue
+			}
+
+			content.Attributes[attr.Name] = &hcl.Attribute{
+				Name:      attr.Name,
+				Expr:      &expression{src: attr.Value},
+				Range:     attr.Range(),
+				NameRange: attr.NameRange,
+			}
+			usedNames[attr.Name] = struct{}{}
+		} else if blockS, defined := blockSchemas[attr.Name]; defined {
+			diags = append(diags, unpackBlock(attr.Value, blockS.Type, attr.NameRange, blockS.LabelNames, nil, nil, &content.Blocks)...)
+			usedNames[attr.Name] = struct{}{}
+		}
+	}
+
+	for _, attrS := range schema.Attributes {
+		if !attrS.Required {
+			continue
+		}
+
+		if _, defined := content.Attributes[attrS.Name]; !defined {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Missing required argument",
+				Detail:   fmt.Sprintf("The argument %q is required, but no definition was found.", attrS.Name),
+				Subject:  b.MissingItemRange().Ptr(),
+			})
+		}
+	}
+
+	return content, &body{val: b.val, hiddenAttrs: usedNames}, diags
+}
+
+func (b *body) JustAttributes() (hcl.Attributes, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	attrs := make(hcl.Attributes)
+
+	obj, ok := b.val.(*Object)
+	if !ok {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Incorrect value type",
+			Detail:   "An object is required here, setting the arguments for this block.",
+			Subject:  b.val.StartRange().Ptr(),
+		})
+		return attrs, diags
+	}
+
+	for _, attr := range obj.Attrs {
+		if _, hidden := b.hiddenAttrs[attr.Name]; hidden {
+			continue
+		}
+
+		attrs[attr.Name] = &hcl.Attribute{
+			Name:      attr.Name,
+			Expr:      &expression{src: attr.Value},
+			Range:     attr.Range(),
+			NameRange: attr.NameRange,
+		}
+	}
+
+	return attrs, diags
+}
+
+func (b *body) MissingItemRange() hcl.Range {
+	return b.val.StartRange()
+}
+
+// unpackBlock appends the blocks defined by the value, consuming nested object properties as labels
+func unpackBlock(v Node, typeName string, typeRange hcl.Range, labelsLeft []string, labelsUsed []string, labelRanges []hcl.Range, blocks *hcl.Blocks) hcl.Diagnostics {
+	if len(labelsLeft) > 0 {
+		labelName := labelsLeft[0]
+		attrs, diags := collectAttrs(v, &labelName)
+
+		if len(attrs) == 0 && !diags.HasErrors() {
+			return diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Missing block label",
+				Detail:   fmt.Sprintf("At least one object property is required, whose name represents the %s block's %s.", typeName, labelName),
+				Subject:  v.StartRange().Ptr(),
+			})
+		}
+
+		for _, attr := range attrs {
+			diags = append(diags, unpackBlock(
+				attr.Value, typeName, typeRange, labelsLeft[1:],
+				append(labelsUsed[:len(labelsUsed):len(labelsUsed)], attr.Name),
+				append(labelRanges[:len(labelRanges):len(labelRanges)], attr.NameRange),
+				blocks,
+			)...)
+		}
+
+		return diags
+	}
+
+	newBlock := func(val Node) *hcl.Block {
+		return &hcl.Block{
+			Type:        typeName,
+			Labels:      labelsUsed,
+			Body:        &body{val: val},
+			DefRange:    val.StartRange(),
+			TypeRange:   typeRange,
+			LabelRanges: labelRanges,
+		}
+	}
+
+	switch tv := v.(type) {
+	case *Object:
+		*blocks = append(*blocks, newBlock(tv))
+	case *Array:
+		for _, av := range tv.Values {
+			*blocks = append(*blocks, newBlock(av))
+		}
+	default:
+		// a null value has no block content
+		if scalar, ok := v.(*Scalar); ok && scalar.Value.IsNull() {
+			return nil
+		}
+
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Incorrect value type",
+			Detail:   fmt.Sprintf("Either an object or a list of objects is required, representing the contents of one or more %q blocks.", typeName),
+			Subject:  v.StartRange().Ptr(),
+		}}
+	}
+
+	return nil
+}
+
+// collectAttrs flattens an object or list of objects into their properties, labelName tailors the diagnostics for
+// objects used as block labels
+func collectAttrs(v Node, labelName *string) ([]*Attr, hcl.Diagnostics) {
+	detail := "An object is required here, to define arguments and child blocks."
+	if labelName != nil {
+		detail = fmt.Sprintf("An object is required here, to specify %s labels for this block.", *labelName)
+	}
+
+	var diags hcl.Diagnostics
+	var attrs []*Attr
+
+	switch tv := v.(type) {
+	case *Object:
+		attrs = append(attrs, tv.Attrs...)
+	case *Array:
+		for _, ev := range tv.Values {
+			obj, ok := ev.(*Object)
+			if !ok {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Incorrect value type",
+					Detail:   detail,
+					Subject:  ev.StartRange().Ptr(),
+				})
+				continue
+			}
+
+			attrs = append(attrs, obj.Attrs...)
+		}
+	case *Scalar:
+		if tv.Value.IsNull() {
+			return nil, nil
+		}
+
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Incorrect value type",
+			Detail:   detail,
+			Subject:  v.StartRange().Ptr(),
+		})
+	}
+
+	return attrs, diags
+}
+
+// nameSuggestion returns the suggestion closest to the given name, or empty when none are close enough
+func nameSuggestion(given string, suggestions []string) string {
+	for _, suggestion := range suggestions {
+		if levenshtein.Distance(given, suggestion, nil) < 3 {
+			return suggestion
+		}
+	}
+
+	return ""
+}
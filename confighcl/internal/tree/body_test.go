@@ -0,0 +1,283 @@
+package tree
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// line returns a range of the test file on the line
+func line(n int) hcl.Range {
+	return hcl.Range{Filename: "test.yaml", Start: hcl.Pos{Line: n, Column: 1}, End: hcl.Pos{Line: n, Column: 2}}
+}
+
+func object(n int, attrs ...*Attr) *Object {
+	return &Object{Attrs: attrs, SrcRange: line(n)}
+}
+
+func attr(n int, name string, value Node) *Attr {
+	return &Attr{Name: name, NameRange: line(n), Value: value}
+}
+
+func array(n int, values ...Node) *Array {
+	return &Array{Values: values, SrcRange: line(n)}
+}
+
+func scalar(n int, value cty.Value) *Scalar {
+	return &Scalar{Value: value, SrcRange: line(n)}
+}
+
+// testBody returns the body of a file with the root object
+func testBody(root *Object) hcl.Body {
+	return NewFile(root, nil).Body
+}
+
+// expectDiagnostic fails the test unless the diagnostics are a single error with the summary and detail
+func expectDiagnostic(t *testing.T, diags hcl.Diagnostics, summary, detail string, subjectLine int) {
+	t.Helper()
+
+	if len(diags) != 1 || diags[0].Severity != hcl.DiagError {
+		t.Fatalf("expected a single error; got %v", diags)
+	}
+	if diags[0].Summary != summary || !strings.Contains(diags[0].Detail, detail) {
+		t.Errorf("unexpected diagnostic: expected %s; %s; got %s; %s", summary, detail, diags[0].Summary, diags[0].Detail)
+	}
+	if diags[0].Subject == nil || diags[0].Subject.Start.Line != subjectLine {
+		t.Errorf("unexpected subject: expected line %d; got %v", subjectLine, diags[0].Subject)
+	}
+}
+
+func TestPartialContent(t *testing.T) {
+	b := testBody(object(1,
+		attr(2, "name", scalar(2, cty.StringVal("app"))),
+		attr(3, "port", scalar(3, cty.NumberIntVal(8080))),
+		attr(4, "listener", array(4,
+			object(5, attr(5, "addr", scalar(5, cty.StringVal(":80")))),
+			object(6, attr(6, "addr", scalar(6, cty.StringVal(":443")))),
+		)),
+		attr(7, "extra", scalar(7, cty.True)),
+	))
+
+	schema := &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "name", Required: true}, {Name: "port"}},
+		Blocks:     []hcl.BlockHeaderSchema{{Type: "listener"}},
+	}
+
+	content, remain, diags := b.PartialContent(schema)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	if len(content.Attributes) != 2 {
+		t.Fatalf("unexpected attributes: %v", content.Attributes)
+	}
+	port, diags := content.Attributes["port"].Expr.Value(nil)
+	if diags.HasErrors() || !port.RawEquals(cty.NumberIntVal(8080)) {
+		t.Errorf("unexpected port: %#v %v", port, diags)
+	}
+	if rng := content.Attributes["name"].NameRange; rng.Start.Line != 2 {
+		t.Errorf("unexpected name range: %v", rng)
+	}
+
+	if len(content.Blocks) != 2 {
+		t.Fatalf("unexpected blocks: %v", content.Blocks)
+	}
+	for i, expected := range []string{":80", ":443"} {
+		block := content.Blocks[i]
+		if block.Type != "listener" || block.TypeRange.Start.Line != 4 || block.DefRange.Start.Line != 5+i {
+			t.Errorf("unexpected block %d: %+v", i, block)
+		}
+
+		attrs, diags := block.Body.JustAttributes()
+		if diags.HasErrors() {
+			t.Fatal(diags.Error())
+		}
+		if addr, _ := attrs["addr"].Expr.Value(nil); !addr.RawEquals(cty.StringVal(expected)) {
+			t.Errorf("unexpected address of block %d: %#v", i, addr)
+		}
+	}
+
+	// the remaining body only has the properties that are not in the schema
+	attrs, diags := remain.JustAttributes()
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	if names := attrNames(attrs); !reflect.DeepEqual(names, []string{"extra"}) {
+		t.Errorf("unexpected remaining attributes: %v", names)
+	}
+
+	t.Run("required", func(t *testing.T) {
+		_, _, diags := testBody(object(1)).PartialContent(schema)
+		expectDiagnostic(t, diags, "Missing required argument", `The argument "name" is required`, 1)
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		// the properties of an array of objects are merged, as for the bodies of repeated blocks
+		_, _, diags := (&body{val: array(1,
+			object(2, attr(2, "port", scalar(2, cty.NumberIntVal(1)))),
+			object(3, attr(3, "port", scalar(3, cty.NumberIntVal(2)))),
+		)}).PartialContent(&hcl.BodySchema{Attributes: []hcl.AttributeSchema{{Name: "port"}}})
+		expectDiagnostic(t, diags, "Duplicate argument", `The argument "port" was already set`, 3)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, diags := testBody(object(1,
+			attr(2, "name", scalar(2, cty.StringVal("app"))),
+			attr(3, "prot", scalar(3, cty.NumberIntVal(8080))),
+		)).Content(schema)
+		expectDiagnostic(t, diags, "Unsupported argument", `No argument or block type is named "prot". Did you mean "port"?`, 3)
+	})
+
+	t.Run("block value", func(t *testing.T) {
+		_, _, diags := testBody(object(1,
+			attr(2, "name", scalar(2, cty.StringVal("app"))),
+			attr(3, "listener", scalar(3, cty.StringVal(":80"))),
+		)).PartialContent(schema)
+		expectDiagnostic(t, diags, "Incorrect value type", `representing the contents of one or more "listener" blocks`, 3)
+	})
+
+	t.Run("null block", func(t *testing.T) {
+		content, _, diags := testBody(object(1,
+			attr(2, "name", scalar(2, cty.StringVal("app"))),
+			attr(3, "listener", scalar(3, cty.NullVal(cty.DynamicPseudoType))),
+		)).PartialContent(schema)
+		if diags.HasErrors() || len(content.Blocks) != 0 {
+			t.Errorf("unexpected blocks of null: %v %v", content.Blocks, diags)
+		}
+	})
+}
+
+func TestJustAttributes(t *testing.T) {
+	attrs, diags := testBody(object(1,
+		attr(2, "name", scalar(2, cty.StringVal("app"))),
+		attr(3, "hosts", array(3, scalar(3, cty.StringVal("a")), scalar(3, cty.StringVal("b")))),
+		attr(4, "labels", object(4, attr(5, "team", scalar(5, cty.StringVal("platform"))))),
+	)).JustAttributes()
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	expected := map[string]cty.Value{
+		"name":  cty.StringVal("app"),
+		"hosts": cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+		"labels": cty.ObjectVal(map[string]cty.Value{
+			"team": cty.StringVal("platform"),
+		}),
+	}
+	if names := attrNames(attrs); !reflect.DeepEqual(names, []string{"hosts", "labels", "name"}) {
+		t.Fatalf("unexpected attributes: %v", names)
+	}
+	for name, value := range expected {
+		if val, diags := attrs[name].Expr.Value(nil); diags.HasErrors() || !val.RawEquals(value) {
+			t.Errorf("unexpected value of %s: expected %#v; got %#v %v", name, value, val, diags)
+		}
+	}
+
+	t.Run("scalar", func(t *testing.T) {
+		_, diags := (&body{val: scalar(2, cty.StringVal("app"))}).JustAttributes()
+		expectDiagnostic(t, diags, "Incorrect value type", "An object is required here", 2)
+	})
+
+	t.Run("template", func(t *testing.T) {
+		attrs, _ := testBody(object(1, attr(1, "greeting", &Scalar{
+			Value:         cty.StringVal("hello ${name}"),
+			SrcRange:      line(1),
+			TemplateStart: hcl.Pos{Line: 1, Column: 1},
+		}))).JustAttributes()
+
+		ctx := &hcl.EvalContext{Variables: map[string]cty.Value{"name": cty.StringVal("world")}}
+		if val, diags := attrs["greeting"].Expr.Value(ctx); diags.HasErrors() || !val.RawEquals(cty.StringVal("hello world")) {
+			t.Errorf("unexpected template value: %#v %v", val, diags)
+		}
+		if val, _ := attrs["greeting"].Expr.Value(nil); !val.RawEquals(cty.StringVal("hello ${name}")) {
+			t.Errorf("unexpected value without a context: %#v", val)
+		}
+	})
+}
+
+func TestBlockLabels(t *testing.T) {
+	schema := &hcl.BodySchema{Blocks: []hcl.BlockHeaderSchema{{Type: "service", LabelNames: []string{"name", "protocol"}}}}
+
+	content, diags := testBody(object(1,
+		attr(1, "service", object(1,
+			attr(2, "web", object(2,
+				attr(3, "http", object(3, attr(4, "port", scalar(4, cty.NumberIntVal(80))))),
+				attr(5, "https", array(5,
+					object(6, attr(6, "port", scalar(6, cty.NumberIntVal(443)))),
+					object(7, attr(7, "port", scalar(7, cty.NumberIntVal(8443)))),
+				)),
+			)),
+			attr(8, "db", object(8,
+				attr(9, "tcp", object(9, attr(10, "port", scalar(10, cty.NumberIntVal(5432))))),
+			)),
+		)),
+	)).Content(schema)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	expected := []struct {
+		Labels     []string
+		LabelLines []int
+		DefLine    int
+		Port       int64
+	}{
+		{Labels: []string{"web", "http"}, LabelLines: []int{2, 3}, DefLine: 3, Port: 80},
+		{Labels: []string{"web", "https"}, LabelLines: []int{2, 5}, DefLine: 6, Port: 443},
+		{Labels: []string{"web", "https"}, LabelLines: []int{2, 5}, DefLine: 7, Port: 8443},
+		{Labels: []string{"db", "tcp"}, LabelLines: []int{8, 9}, DefLine: 9, Port: 5432},
+	}
+
+	if len(content.Blocks) != len(expected) {
+		t.Fatalf("unexpected blocks: %v", content.Blocks)
+	}
+
+	for i, e := range expected {
+		block := content.Blocks[i]
+		if !reflect.DeepEqual(block.Labels, e.Labels) || block.DefRange.Start.Line != e.DefLine {
+			t.Errorf("unexpected block %d: expected %v at line %d; got %v at line %d", i, e.Labels, e.DefLine, block.Labels, block.DefRange.Start.Line)
+		}
+
+		var lines []int
+		for _, rng := range block.LabelRanges {
+			lines = append(lines, rng.Start.Line)
+		}
+		if !reflect.DeepEqual(lines, e.LabelLines) {
+			t.Errorf("unexpected label ranges of block %d: expected lines %v; got %v", i, e.LabelLines, lines)
+		}
+
+		attrs, _ := block.Body.JustAttributes()
+		if port, _ := attrs["port"].Expr.Value(nil); !port.RawEquals(cty.NumberIntVal(e.Port)) {
+			t.Errorf("unexpected port of block %d: %#v", i, port)
+		}
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		_, diags := testBody(object(1,
+			attr(1, "service", object(1, attr(2, "web", object(2)))),
+		)).Content(schema)
+		expectDiagnostic(t, diags, "Missing block label", "the service block's protocol", 2)
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		_, diags := testBody(object(1,
+			attr(1, "service", object(1, attr(2, "web", scalar(2, cty.StringVal("http"))))),
+		)).Content(schema)
+		expectDiagnostic(t, diags, "Incorrect value type", "to specify protocol labels for this block", 2)
+	})
+}
+
+// attrNames returns the sorted names of the attributes
+func attrNames(attrs hcl.Attributes) []string {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
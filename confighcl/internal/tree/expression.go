@@ -0,0 +1,123 @@
+package tree
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// expression is the hcl.Expression of a node
+type expression struct {
+	src Node
+}
+
+func (e *expression) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
+	switch v := e.src.(type) {
+	case *Scalar:
+		template, diags := v.template()
+		if template == nil || ctx == nil {
+			return v.Value, nil
+		}
+		if diags.HasErrors() {
+			return cty.DynamicVal, diags
+		}
+
+		return template.Value(ctx)
+
+	case *Array:
+		var diags hcl.Diagnostics
+		vals := make([]cty.Value, 0, len(v.Values))
+		for _, av := range v.Values {
+			val, valDiags := (&expression{src: av}).Value(ctx)
+			vals = append(vals, val)
+			diags = append(diags, valDiags...)
+		}
+
+		return cty.TupleVal(vals), diags
+
+	case *Object:
+		var diags hcl.Diagnostics
+		attrs := make(map[string]cty.Value, len(v.Attrs))
+		ranges := make(map[string]hcl.Range, len(v.Attrs))
+		for _, attr := range v.Attrs {
+			if existing, defined := ranges[attr.Name]; defined {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate object attribute",
+					Detail:   fmt.Sprintf("An attribute named %q was already defined at %s.", attr.Name, existing),
+					Subject:  attr.NameRange.Ptr(),
+				})
+				continue
+			}
+
+			val, valDiags := (&expression{src: attr.Value}).Value(ctx)
+			attrs[attr.Name] = val
+			ranges[attr.Name] = attr.NameRange
+			diags = append(diags, valDiags...)
+		}
+
+		return cty.ObjectVal(attrs), diags
+	}
+
+	return cty.DynamicVal, nil
+}
+
+func (e *expression) Variables() []hcl.Traversal {
+	var vars []hcl.Traversal
+
+	switch v := e.src.(type) {
+	case *Scalar:
+		if template, diags := v.template(); template != nil && !diags.HasErrors() {
+			vars = append(vars, template.Variables()...)
+		}
+	case *Array:
+		for _, av := range v.Values {
+			vars = append(vars, (&expression{src: av}).Variables()...)
+		}
+	case *Object:
+		for _, attr := range v.Attrs {
+			vars = append(vars, (&expression{src: attr.Value}).Variables()...)
+		}
+	}
+
+	return vars
+}
+
+func (e *expression) Range() hcl.Range {
+	return e.src.Range()
+}
+
+func (e *expression) StartRange() hcl.Range {
+	return e.src.StartRange()
+}
+
+// ExprList allows hcl.ExprList to treat arrays as lists of expressions
+func (e *expression) ExprList() []hcl.Expression {
+	switch v := e.src.(type) {
+	case *Array:
+		exprs := make([]hcl.Expression, len(v.Values))
+		for i, av := range v.Values {
+			exprs[i] = &expression{src: av}
+		}
+		return exprs
+	}
+
+	return nil
+}
+
+// template parses string values containing interpolation or directive sequences, nil when there are none
+func (s *Scalar) template() (hclsyntax.Expression, hcl.Diagnostics) {
+	if s.Value.IsNull() || s.Value.Type() != cty.String {
+		return nil, nil
+	}
+
+	src := s.Value.AsString()
+	if !strings.Contains(src, "${") && !strings.Contains(src, "%{") {
+		return nil, nil
+	}
+
+	return hclsyntax.ParseTemplate([]byte(src), s.SrcRange.Filename, s.TemplateStart)
+}
@@ -0,0 +1,80 @@
+package tree
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Source converts the positions reported by parsers into hcl positions
+type Source struct {
+	Filename string
+
+	src   []byte
+	lines []int // byte offset of the start of each line
+}
+
+// NewSource for the source of the file
+func NewSource(filename string, src []byte) *Source {
+	lines := []int{0}
+	for i, b := range src {
+		if b == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+
+	return &Source{Filename: filename, src: src, lines: lines}
+}
+
+// PosAt returns the position of the line and column, both starting at 1 with columns counted in characters
+func (s *Source) PosAt(line, column int) hcl.Pos {
+	if line < 1 {
+		line = 1
+	}
+	if line > len(s.lines) {
+		line = len(s.lines)
+	}
+
+	offset := s.lines[line-1]
+	for c := 1; c < column && offset < len(s.src) && s.src[offset] != '\n'; c++ {
+		_, size := utf8.DecodeRune(s.src[offset:])
+		offset += size
+	}
+
+	return hcl.Pos{Line: line, Column: column, Byte: offset}
+}
+
+// PosAtByte returns the position of the byte offset
+func (s *Source) PosAtByte(offset int) hcl.Pos {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(s.src) {
+		offset = len(s.src)
+	}
+
+	line := sort.Search(len(s.lines), func(i int) bool { return s.lines[i] > offset })
+
+	return hcl.Pos{
+		Line:   line,
+		Column: utf8.RuneCount(s.src[s.lines[line-1]:offset]) + 1,
+		Byte:   offset,
+	}
+}
+
+// Advance returns the position the number of characters after pos on the same line
+func (s *Source) Advance(pos hcl.Pos, characters int) hcl.Pos {
+	for ; characters > 0 && pos.Byte < len(s.src) && s.src[pos.Byte] != '\n'; characters-- {
+		_, size := utf8.DecodeRune(s.src[pos.Byte:])
+		pos.Byte += size
+		pos.Column++
+	}
+
+	return pos
+}
+
+// Range between the positions
+func (s *Source) Range(start, end hcl.Pos) hcl.Range {
+	return hcl.Range{Filename: s.Filename, Start: start, End: end}
+}
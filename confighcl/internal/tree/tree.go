@@ -0,0 +1,91 @@
+// Package tree implements hcl.Body and hcl.Expression for configuration formats that decode into a tree of objects,
+// arrays and scalar values, such as YAML and TOML.
+//
+// The bodies follow the same conventions as the HCL JSON syntax: object properties are attributes or blocks depending on
+// the schema, block labels are nested object properties, arrays of objects are multiple blocks, and strings are
+// evaluated as HCL templates when an hcl.EvalContext is available.
+package tree
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Node of a configuration tree
+type Node interface {
+	Range() hcl.Range
+	StartRange() hcl.Range
+}
+
+// Object is a node with ordered named properties
+type Object struct {
+	Attrs    []*Attr
+	SrcRange hcl.Range
+}
+
+// Attr is a property of an Object
+type Attr struct {
+	Name      string
+	NameRange hcl.Range
+	Value     Node
+}
+
+// Array is a node with ordered values
+type Array struct {
+	Values   []Node
+	SrcRange hcl.Range
+}
+
+// Scalar is a node with a primitive value, string values are evaluated as templates
+type Scalar struct {
+	Value    cty.Value
+	SrcRange hcl.Range
+
+	// TemplateStart is the position of the first character of a string value within the source
+	TemplateStart hcl.Pos
+}
+
+// Range of the object
+func (o *Object) Range() hcl.Range { return o.SrcRange }
+
+// StartRange of the object
+func (o *Object) StartRange() hcl.Range { return startRange(o.SrcRange) }
+
+// Get the property with the specified name, nil when not found
+func (o *Object) Get(name string) *Attr {
+	for _, attr := range o.Attrs {
+		if attr.Name == name {
+			return attr
+		}
+	}
+
+	return nil
+}
+
+// Range of the property from the name through the value
+func (a *Attr) Range() hcl.Range { return hcl.RangeBetween(a.NameRange, a.Value.Range()) }
+
+// Range of the array
+func (a *Array) Range() hcl.Range { return a.SrcRange }
+
+// StartRange of the array
+func (a *Array) StartRange() hcl.Range { return startRange(a.SrcRange) }
+
+// Range of the scalar
+func (s *Scalar) Range() hcl.Range { return s.SrcRange }
+
+// StartRange of the scalar
+func (s *Scalar) StartRange() hcl.Range { return s.SrcRange }
+
+// NewFile creates an hcl.File with the root object as the body
+func NewFile(root *Object, src []byte) *hcl.File {
+	return &hcl.File{
+		Body:  &body{val: root},
+		Bytes: src,
+	}
+}
+
+// startRange is the zero length range at the start of r
+func startRange(r hcl.Range) hcl.Range {
+	return hcl.Range{Filename: r.Filename, Start: r.Start, End: r.Start}
+}
@@ -0,0 +1,389 @@
+package confighcl
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/gocty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// JSONSchemaDialect is the JSON Schema version of the generated schemas
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// durationPattern matches the strings accepted by time.ParseDuration
+const durationPattern = `^[-+]?(0|([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|μs|ms|s|m|h))+$`
+
+// jsonSchemaFormats are the formats of the handled types that JSON Schema defines
+var jsonSchemaFormats = map[reflect.Type]string{
+	reflect.TypeOf((*url.URL)(nil)):       "uri-reference",
+	reflect.TypeOf((*regexp.Regexp)(nil)): "regex",
+	reflect.TypeOf(time.Time{}):           "date-time",
+}
+
+// JSONSchema is a JSON Schema document, or a subschema of one, describing a configuration in the HCL JSON syntax
+type JSONSchema struct {
+	Schema      string `json:"$schema,omitempty"`
+	Ref         string `json:"$ref,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Format      string `json:"format,omitempty"`
+	Pattern     string `json:"pattern,omitempty"`
+
+	Enum    []interface{}   `json:"enum,omitempty"`
+	Default json.RawMessage `json:"default,omitempty"`
+
+	Minimum       *float64 `json:"minimum,omitempty"`
+	Maximum       *float64 `json:"maximum,omitempty"`
+	MinLength     *int     `json:"minLength,omitempty"`
+	MaxLength     *int     `json:"maxLength,omitempty"`
+	MinItems      *int     `json:"minItems,omitempty"`
+	MaxItems      *int     `json:"maxItems,omitempty"`
+	MinProperties *int     `json:"minProperties,omitempty"`
+	MaxProperties *int     `json:"maxProperties,omitempty"`
+
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	PrefixItems          []*JSONSchema          `json:"prefixItems,omitempty"`
+
+	AllOf []*JSONSchema `json:"allOf,omitempty"`
+	AnyOf []*JSONSchema `json:"anyOf,omitempty"`
+
+	Defs map[string]*JSONSchema `json:"$defs,omitempty"`
+
+	// disallow is the false schema, which no value is valid against
+	disallow bool
+}
+
+// MarshalJSON encodes the schema, the false schema is encoded as false
+func (s *JSONSchema) MarshalJSON() ([]byte, error) {
+	if s.disallow {
+		return []byte("false"), nil
+	}
+
+	type schema JSONSchema
+	return json.Marshal((*schema)(s))
+}
+
+// FalseJSONSchema returns the schema no value is valid against, such as for additionalProperties of closed objects
+func FalseJSONSchema() *JSONSchema {
+	return &JSONSchema{disallow: true}
+}
+
+// ImpliedJSONSchema produces a JSON Schema subschema of the object defining the configuration of the given value, which
+// must be a struct value or a pointer to one with the struct tags defined in this package.
+//
+// Attributes are properties with the type of their field, required unless optional or defaulted, and include the
+// defaults and the constraints of the validate tags that JSON Schema can represent. Blocks are objects, or arrays of
+// objects when decoded into slices, nested in an object for each label.
+//
+// The properties of the top level object are not closed, since the configuration of other modules shares the same
+// body. Nested blocks do not allow additional properties unless they have a "remain" field.
+func ImpliedJSONSchema(val interface{}) *JSONSchema {
+	ty := reflect.TypeOf(val)
+	if ty.Kind() == reflect.Ptr {
+		ty = ty.Elem()
+	}
+	if ty.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("given value must be struct, not %T", val))
+	}
+
+	return structJSONSchema(ty, map[reflect.Type]bool{})
+}
+
+// structJSONSchema produces the schema of the body of the struct type, visiting is the set of block types on the
+// current path to stop recursive types
+func structJSONSchema(ty reflect.Type, visiting map[reflect.Type]bool) *JSONSchema {
+	visiting[ty] = true
+	defer delete(visiting, ty)
+
+	schema, _ := ImpliedBodySchema(reflect.New(ty).Interface())
+	tags := getFieldTags(ty)
+
+	result := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}}
+
+	for _, attrS := range schema.Attributes {
+		field := ty.Field(tags.Attributes[attrS.Name])
+
+		prop := typeJSONSchema(field.Type)
+		prop.Description = tags.Descriptions[attrS.Name]
+		if src, hasDefault := tags.Defaults[attrS.Name]; hasDefault {
+			prop.Default = defaultJSON(attrS.Name, src, field.Type)
+		}
+		for _, rule := range tags.Validate[attrS.Name] {
+			rule.applyJSONSchema(prop, field.Type)
+		}
+
+		result.Properties[attrS.Name] = prop
+		if attrS.Required {
+			result.Required = append(result.Required, attrS.Name)
+		}
+	}
+
+	for _, blockS := range schema.Blocks {
+		fty := ty.Field(tags.Blocks[blockS.Type]).Type
+
+		isSlice := fty.Kind() == reflect.Slice
+		if isSlice {
+			fty = fty.Elem()
+		}
+
+		isPtr := fty.Kind() == reflect.Ptr
+		if isPtr {
+			fty = fty.Elem()
+		}
+
+		var block *JSONSchema
+		switch {
+		case visiting[fty]:
+			// recursive blocks are not described any further
+			block = &JSONSchema{Type: "object"}
+		default:
+			block = structJSONSchema(fty, visiting)
+			if getFieldTags(fty).Remain == nil {
+				block.AdditionalProperties = FalseJSONSchema()
+			}
+		}
+
+		if isSlice {
+			// a single block may also be written as an object
+			block = &JSONSchema{AnyOf: []*JSONSchema{block, {Type: "array", Items: block}}}
+		}
+
+		// labels are the keys of nested objects, from the innermost
+		for i := len(blockS.LabelNames) - 1; i >= 0; i-- {
+			block = &JSONSchema{
+				Type:                 "object",
+				Description:          fmt.Sprintf("%s blocks keyed by %s", blockS.Type, blockS.LabelNames[i]),
+				AdditionalProperties: block,
+			}
+		}
+
+		if description := tags.Descriptions[blockS.Type]; description != "" {
+			block.Description = description
+		}
+
+		result.Properties[blockS.Type] = block
+		if !isSlice && !isPtr && !tags.DefaultBlocks[blockS.Type] {
+			result.Required = append(result.Required, blockS.Type)
+		}
+	}
+
+	sort.Strings(result.Required)
+
+	return result
+}
+
+// typeJSONSchema produces the schema of an attribute value of the type
+func typeJSONSchema(ty reflect.Type) *JSONSchema {
+	if exprType.AssignableTo(ty) || attrType.AssignableTo(ty) {
+		return &JSONSchema{}
+	}
+
+	if handler, ok := fieldHandler(ty); ok {
+		schema := ctyJSONSchema(handler.Type)
+		if indirectType(ty) == durationType {
+			schema.Pattern = durationPattern
+		}
+		if format, ok := jsonSchemaFormats[ty]; ok {
+			schema.Format = format
+		} else if format, ok := jsonSchemaFormats[indirectType(ty)]; ok {
+			schema.Format = format
+		}
+
+		// the elements of collections of handled types have the schema of their type
+		switch elemTy := indirectType(ty); {
+		case elemTy.Kind() == reflect.Slice && schema.Items != nil:
+			schema.Items = typeJSONSchema(elemTy.Elem())
+		case elemTy.Kind() == reflect.Map && schema.AdditionalProperties != nil:
+			schema.AdditionalProperties = typeJSONSchema(elemTy.Elem())
+		}
+		return schema
+	}
+
+	ty = indirectType(ty)
+
+	switch ty.Kind() {
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: typeJSONSchema(ty.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: typeJSONSchema(ty.Elem())}
+	}
+
+	ctyTy, err := gocty.ImpliedType(reflect.New(ty).Interface())
+	if err != nil {
+		return &JSONSchema{}
+	}
+
+	return ctyJSONSchema(ctyTy)
+}
+
+// ctyJSONSchema produces the schema of values of the cty type
+func ctyJSONSchema(ty cty.Type) *JSONSchema {
+	switch {
+	case ty == cty.String:
+		return &JSONSchema{Type: "string"}
+	case ty == cty.Number:
+		return &JSONSchema{Type: "number"}
+	case ty == cty.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case ty.IsListType() || ty.IsSetType():
+		return &JSONSchema{Type: "array", Items: ctyJSONSchema(ty.ElementType())}
+	case ty.IsMapType():
+		return &JSONSchema{Type: "object", AdditionalProperties: ctyJSONSchema(ty.ElementType())}
+	case ty.IsTupleType():
+		schema := &JSONSchema{Type: "array"}
+		for _, ety := range ty.TupleElementTypes() {
+			schema.PrefixItems = append(schema.PrefixItems, ctyJSONSchema(ety))
+		}
+		return schema
+	case ty.IsObjectType():
+		schema := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}, AdditionalProperties: FalseJSONSchema()}
+		for name, aty := range ty.AttributeTypes() {
+			schema.Properties[name] = ctyJSONSchema(aty)
+			if !ty.AttributeOptional(name) {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+		sort.Strings(schema.Required)
+		return schema
+	}
+
+	return &JSONSchema{}
+}
+
+// defaultJSON returns the JSON of the default tag value converted to the type of the field, nil when it cannot be
+// represented without evaluating functions
+func defaultJSON(name, src string, ty reflect.Type) json.RawMessage {
+	val := cty.StringVal(src)
+	if expr, ok := parseDefault(name, src); ok {
+		v, diags := expr.Value(nil)
+		if diags.HasErrors() {
+			return nil
+		}
+		val = v
+	}
+
+	ctyTy, err := gocty.ImpliedType(reflect.New(ty).Interface())
+	if handler, ok := fieldHandler(ty); ok {
+		ctyTy, err = handler.Type, nil
+	}
+	if err == nil {
+		if converted, err := convert.Convert(val, ctyTy); err == nil {
+			val = converted
+		}
+	}
+
+	b, err := ctyjson.SimpleJSONValue{Value: val}.MarshalJSON()
+	if err != nil {
+		return nil
+	}
+
+	return b
+}
+
+// applyJSONSchema adds the constraint of the rule to the schema of the attribute of the type, rules that cannot be
+// represented are ignored
+func (r validationRule) applyJSONSchema(schema *JSONSchema, ty reflect.Type) {
+	// the limits and patterns of the values of handled types do not apply to their configuration values, while those of
+	// collections of handled types limit their length
+	handler, isHandled := fieldHandler(ty)
+	isHandled = isHandled && !handler.Type.IsListType() && !handler.Type.IsMapType()
+
+	intArg := func() *int {
+		var n int
+		if _, err := fmt.Sscan(r.arg, &n); err != nil {
+			return nil
+		}
+		return &n
+	}
+
+	switch r.name {
+	case "nonempty":
+		one := 1
+		switch schema.Type {
+		case "string":
+			schema.MinLength = &one
+		case "array":
+			schema.MinItems = &one
+		case "object":
+			schema.MinProperties = &one
+		}
+
+	case "min", "max":
+		if isHandled {
+			return
+		}
+
+		limit, _ := limitValue(ty, r.arg)
+		switch schema.Type {
+		case "string":
+			if r.name == "min" {
+				schema.MinLength = intArg()
+			} else {
+				schema.MaxLength = intArg()
+			}
+		case "array":
+			if r.name == "min" {
+				schema.MinItems = intArg()
+			} else {
+				schema.MaxItems = intArg()
+			}
+		case "object":
+			if r.name == "min" {
+				schema.MinProperties = intArg()
+			} else {
+				schema.MaxProperties = intArg()
+			}
+		case "integer", "number":
+			if r.name == "min" {
+				schema.Minimum = &limit
+			} else {
+				schema.Maximum = &limit
+			}
+		}
+
+	case "oneof":
+		for _, option := range strings.Fields(r.arg) {
+			var value interface{} = option
+			if schema.Type == "integer" || schema.Type == "number" {
+				value = json.Number(option)
+			}
+			schema.Enum = append(schema.Enum, value)
+		}
+
+	case "regex":
+		if schema.Type == "string" && !isHandled {
+			schema.Pattern = r.arg
+		}
+
+	case "port":
+		if schema.Type == "integer" {
+			low, high := 1.0, 65535.0
+			schema.Minimum, schema.Maximum = &low, &high
+		}
+
+	case "url":
+		schema.Format = "uri"
+	}
+}
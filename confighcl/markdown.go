@@ -0,0 +1,139 @@
+package confighcl
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// MarkdownReference produces Markdown reference documentation of the configuration of the given value, which must be
+// a struct value or a pointer to one with the struct tags defined in this package.
+//
+// The attributes are listed in a table with their type, Go type, whether they are required, their default and the
+// text of their description tag. Each block is a section with a heading of the given level, describing its labels and
+// its own attributes and blocks, with nested blocks at the following levels.
+func MarkdownReference(val interface{}, level int) []byte {
+	ty := reflect.TypeOf(val)
+	if ty.Kind() == reflect.Ptr {
+		ty = ty.Elem()
+	}
+	if ty.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("given value must be struct, not %T", val))
+	}
+
+	var buf bytes.Buffer
+	writeMarkdownBody(&buf, ty, nil, level, map[reflect.Type]bool{})
+
+	return buf.Bytes()
+}
+
+// writeMarkdownBody writes the reference of the body of the struct type, visiting is the set of block types on the
+// current path to stop recursive types
+func writeMarkdownBody(buf *bytes.Buffer, ty reflect.Type, path []string, level int, visiting map[reflect.Type]bool) {
+	visiting[ty] = true
+	defer delete(visiting, ty)
+
+	tags := getFieldTags(ty)
+
+	var attrs, blocks []string
+	for name := range tags.Attributes {
+		attrs = append(attrs, name)
+	}
+	for name := range tags.Blocks {
+		blocks = append(blocks, name)
+	}
+	sort.Slice(attrs, func(i, j int) bool { return tags.Attributes[attrs[i]] < tags.Attributes[attrs[j]] })
+	sort.Slice(blocks, func(i, j int) bool { return tags.Blocks[blocks[i]] < tags.Blocks[blocks[j]] })
+
+	if len(attrs) > 0 {
+		separate(buf)
+		buf.WriteString("| Name | Type | Go type | Required | Default | Description |\n")
+		buf.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	}
+
+	for _, name := range attrs {
+		fty := ty.Field(tags.Attributes[name]).Type
+
+		typeName := "any"
+		if !exprType.AssignableTo(fty) && !attrType.AssignableTo(fty) {
+			typeName = fieldTypeName(fty)
+		}
+
+		required, def := "yes", ""
+		if src, hasDefault := tags.Defaults[name]; hasDefault {
+			required, def = "no", markdownCode(src)
+		} else if tags.Optional[name] {
+			required = "no"
+		}
+
+		fmt.Fprintf(buf, "| %s | %s | %s | %s | %s | %s |\n",
+			markdownCode(name), markdownCell(typeName), markdownCode(fty.String()), required, def, markdownCell(tags.Descriptions[name]))
+	}
+
+	if tags.Remain != nil {
+		separate(buf)
+		buf.WriteString("Other attributes and blocks are allowed.\n")
+	}
+
+	for _, name := range blocks {
+		fty := ty.Field(tags.Blocks[name]).Type
+		blockPath := append(path[:len(path):len(path)], name)
+
+		summary := "Required."
+		switch {
+		case fty.Kind() == reflect.Slice:
+			summary = "Any number of blocks."
+			fty = fty.Elem()
+		case fty.Kind() == reflect.Ptr || tags.DefaultBlocks[name]:
+			summary = "Optional."
+		}
+		fty = indirectType(fty)
+
+		separate(buf)
+		fmt.Fprintf(buf, "%s %s block\n\n", strings.Repeat("#", min(level, 6)), markdownCode(strings.Join(blockPath, ".")))
+
+		if fty.Kind() == reflect.Struct {
+			if labels := getFieldTags(fty).Labels; len(labels) > 0 {
+				names := make([]string, len(labels))
+				for i, label := range labels {
+					names[i] = markdownCode(label.Name)
+				}
+				summary = fmt.Sprintf("%s Labeled by %s.", summary, strings.Join(names, " and "))
+			}
+		}
+
+		buf.WriteString(summary)
+		if description := tags.Descriptions[name]; description != "" {
+			buf.WriteString(" " + description)
+		}
+		buf.WriteString("\n")
+
+		switch {
+		case fty.Kind() != reflect.Struct:
+			// blocks decoded into hcl.Body or hcl.Attributes values have no schema
+		case visiting[fty]:
+			buf.WriteString("\nThe same attributes and blocks as its enclosing block.\n")
+		default:
+			writeMarkdownBody(buf, fty, blockPath, level+1, visiting)
+		}
+	}
+}
+
+// separate starts a new paragraph after any previous content
+func separate(buf *bytes.Buffer) {
+	if buf.Len() > 0 {
+		buf.WriteString("\n")
+	}
+}
+
+// markdownCode returns the text as inline code, in table cells
+func markdownCode(text string) string {
+	return "`" + markdownCell(text) + "`"
+}
+
+// markdownCell escapes the text for a table cell
+func markdownCell(text string) string {
+	return strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ").Replace(text)
+}
@@ -0,0 +1,144 @@
+package confighcl
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// EncodeSampleIntoBody replaces the contents of the given hclwrite Body with a sample configuration of the given value,
+// with the same constraints as EncodeIntoBody.
+//
+// Every attribute and block is preceded by a comment with its description tag, naming its type and whether it is
+// required. Absent optional attributes and blocks, and an example of blocks decoded into empty slices, are included
+// commented out so the sample decodes to the same value.
+func EncodeSampleIntoBody(val interface{}, dst *hclwrite.Body) {
+	rv := reflect.ValueOf(val)
+	ty := rv.Type()
+	if ty.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+		ty = rv.Type()
+	}
+	if ty.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("value is %s, not struct", ty.Kind()))
+	}
+
+	tags := getFieldTags(ty)
+	populateBody(rv, ty, tags, dst, true)
+}
+
+// attributeComment describes the attribute of the field type
+func attributeComment(name string, ty reflect.Type, tags *fieldTags) hclwrite.Tokens {
+	desc := fieldTypeName(ty)
+	if _, hasDefault := tags.Defaults[name]; !tags.Optional[name] && !hasDefault {
+		desc += ", required"
+	}
+
+	return append(descriptionComment(tags.Descriptions[name]), Comment(fmt.Sprintf("%s (%s)", name, desc))...)
+}
+
+// blockComment describes the block of the field type
+func blockComment(name string, ty reflect.Type, tags *fieldTags) hclwrite.Tokens {
+	desc := "block, required"
+	switch {
+	case ty.Kind() == reflect.Slice || ty.Kind() == reflect.Array:
+		desc = "blocks"
+		ty = ty.Elem()
+	case ty.Kind() == reflect.Ptr || tags.DefaultBlocks[name]:
+		desc = "block, optional"
+	}
+
+	ty = indirectType(ty)
+	if labels := getFieldTags(ty).Labels; len(labels) > 0 {
+		names := make([]string, len(labels))
+		for i, label := range labels {
+			names[i] = label.Name
+		}
+		desc += ", labeled by " + strings.Join(names, " and ")
+	}
+
+	return append(descriptionComment(tags.Descriptions[name]), Comment(fmt.Sprintf("%s (%s)", name, desc))...)
+}
+
+// descriptionComment returns the lines of the description as comments
+func descriptionComment(description string) hclwrite.Tokens {
+	var tokens hclwrite.Tokens
+	if description == "" {
+		return tokens
+	}
+
+	for _, line := range strings.Split(description, "\n") {
+		tokens = append(tokens, Comment(line)...)
+	}
+
+	return tokens
+}
+
+// sampleAttribute returns the source of the attribute set to the zero value of the type, or an empty collection
+func sampleAttribute(name string, ty reflect.Type) []byte {
+	v := reflect.New(ty).Elem()
+	if handler, ok := fieldHandler(ty); !ok || handler.Type.IsListType() || handler.Type.IsMapType() {
+		ty = indirectType(ty)
+		v = reflect.New(ty).Elem()
+
+		switch ty.Kind() {
+		case reflect.Slice:
+			v = reflect.MakeSlice(ty, 0, 0)
+		case reflect.Map:
+			v = reflect.MakeMap(ty)
+		}
+	}
+
+	f := hclwrite.NewEmptyFile()
+	setAttributeValue(f.Body(), name, v)
+
+	return f.Bytes()
+}
+
+// sampleSecret returns the source of a secret attribute with an empty string, since its value is never written
+func sampleSecret(name string) []byte {
+	f := hclwrite.NewEmptyFile()
+	f.Body().SetAttributeValue(name, cty.StringVal(""))
+
+	return f.Bytes()
+}
+
+// sampleBlock returns the source of a block of the struct type with its zero values, labeled by the label names
+func sampleBlock(name string, ty reflect.Type) []byte {
+	rv := reflect.New(ty).Elem()
+	for _, label := range getFieldTags(ty).Labels {
+		if lv := rv.Field(label.FieldIndex); lv.Kind() == reflect.String {
+			lv.SetString(label.Name)
+		}
+	}
+
+	f := hclwrite.NewEmptyFile()
+	f.Body().AppendBlock(EncodeAsBlock(rv.Addr().Interface(), name))
+
+	return f.Bytes()
+}
+
+// isNilCollection returns whether the value is a nil slice or map, which is encoded as null
+func isNilCollection(v reflect.Value) bool {
+	return (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.IsNil()
+}
+
+// Comment returns the tokens of a line comment, such as the comments of sample configurations
+func Comment(text string) hclwrite.Tokens {
+	return hclwrite.Tokens{{Type: hclsyntax.TokenComment, Bytes: []byte("# " + text + "\n")}}
+}
+
+// commentOut returns the formatted source as line comments
+func commentOut(src []byte) hclwrite.Tokens {
+	var tokens hclwrite.Tokens
+	for _, line := range bytes.Split(bytes.TrimRight(hclwrite.Format(src), "\n"), []byte("\n")) {
+		tokens = append(tokens, Comment(string(line))...)
+	}
+
+	return tokens
+}
@@ -43,6 +43,7 @@ func ImpliedBodySchema(val interface{}) (schema *hcl.BodySchema, partial bool) {
 	for _, n := range attrNames {
 		idx := tags.Attributes[n]
 		optional := tags.Optional[n]
+		_, hasDefault := tags.Defaults[n]
 		field := ty.Field(idx)
 
 		var required bool
@@ -53,7 +54,7 @@ func ImpliedBodySchema(val interface{}) (schema *hcl.BodySchema, partial bool) {
 			// indicated via a null value, so we don't specify that
 			// the field is required during decoding.
 			required = false
-		case field.Type.Kind() != reflect.Ptr && !optional:
+		case field.Type.Kind() != reflect.Ptr && !optional && !hasDefault:
 			required = true
 		default:
 			required = false
@@ -94,6 +95,10 @@ func ImpliedBodySchema(val interface{}) (schema *hcl.BodySchema, partial bool) {
 			}
 		}
 
+		if tags.DefaultBlocks[n] && len(labelNames) > 0 {
+			panic(fmt.Sprintf("hcl 'default' tag option cannot be applied to block %s with labels", n))
+		}
+
 		blockSchemas = append(blockSchemas, hcl.BlockHeaderSchema{
 			Type:       n,
 			LabelNames: labelNames,
@@ -109,11 +114,15 @@ func ImpliedBodySchema(val interface{}) (schema *hcl.BodySchema, partial bool) {
 }
 
 type fieldTags struct {
-	Attributes map[string]int
-	Blocks     map[string]int
-	Labels     []labelField
-	Remain     *int
-	Optional   map[string]bool
+	Attributes    map[string]int
+	Blocks        map[string]int
+	Labels        []labelField
+	Remain        *int
+	Optional      map[string]bool
+	Defaults      map[string]string
+	DefaultBlocks map[string]bool
+	Validate      map[string][]validationRule
+	Descriptions  map[string]string
 }
 
 type labelField struct {
@@ -123,9 +132,13 @@ type labelField struct {
 
 func getFieldTags(ty reflect.Type) *fieldTags {
 	ret := &fieldTags{
-		Attributes: map[string]int{},
-		Blocks:     map[string]int{},
-		Optional:   map[string]bool{},
+		Attributes:    map[string]int{},
+		Blocks:        map[string]int{},
+		Optional:      map[string]bool{},
+		Defaults:      map[string]string{},
+		DefaultBlocks: map[string]bool{},
+		Validate:      map[string][]validationRule{},
+		Descriptions:  map[string]string{},
 	}
 
 	ct := ty.NumField()
@@ -151,6 +164,24 @@ func getFieldTags(ty reflect.Type) *fieldTags {
 			kind = "attr"
 		}
 
+		// the default option is last, so its value can contain commas
+		var defaultVal string
+		var hasDefault, bareDefault bool
+		if idx := strings.Index(","+kind, ",default"); idx != -1 {
+			option := kind[idx:]
+			kind = strings.TrimSuffix(kind[:idx], ",")
+			if kind == "" {
+				kind = "attr"
+			}
+
+			hasDefault = true
+			bareDefault = option == "default"
+			if !bareDefault && !strings.HasPrefix(option, "default=") {
+				panic(fmt.Sprintf("invalid %s field tag option %q on %s %q", tag, option, field.Type.String(), field.Name))
+			}
+			defaultVal = strings.TrimPrefix(option, "default=")
+		}
+
 		switch kind {
 		case "attr":
 			ret.Attributes[name] = i
@@ -173,6 +204,31 @@ func getFieldTags(ty reflect.Type) *fieldTags {
 		default:
 			panic(fmt.Sprintf("invalid %s field tag kind %q on %s %q", tag, kind, field.Type.String(), field.Name))
 		}
+
+		if rules := field.Tag.Get("validate"); rules != "" {
+			if kind != "attr" && kind != "optional" {
+				panic(fmt.Sprintf("validate tag cannot be applied to %s field %s: attribute required", kind, field.Name))
+			}
+			ret.Validate[name] = parseValidationRules(rules, field)
+		}
+
+		if description := field.Tag.Get("description"); description != "" {
+			if kind == "label" || kind == "remain" {
+				panic(fmt.Sprintf("description tag cannot be applied to %s field %s: attribute or block required", kind, field.Name))
+			}
+			ret.Descriptions[name] = description
+		}
+
+		if hasDefault {
+			switch {
+			case (kind == "attr" || kind == "optional") && !bareDefault:
+				ret.Defaults[name] = defaultVal
+			case kind == "block" && bareDefault && field.Type.Kind() != reflect.Slice:
+				ret.DefaultBlocks[name] = true
+			default:
+				panic(fmt.Sprintf("invalid %s field tag default on %s %q: attributes require a value and only single blocks without a value are supported", tag, field.Type.String(), field.Name))
+			}
+		}
 	}
 
 	return ret
@@ -0,0 +1,83 @@
+package confighcl
+
+import (
+	"log/slog"
+	"reflect"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// redacted replaces secrets when they are printed or logged
+const redacted = "REDACTED"
+
+// secretMark is the type of SecretMark, so no other package can create the mark
+type secretMark struct{}
+
+// SecretMark marks configuration values that are secrets, such as the values returned by functions resolving secrets.
+// Values with the mark, and the values computed from them, can only be decoded into Secret attributes.
+var SecretMark = secretMark{}
+
+// Secret is a string configuration value that is a secret, such as a password. It is printed and logged with slog as
+// REDACTED, and never written by EncodeIntoBody. Convert it to a string to use it:
+//
+//	Password Secret `config:"password"`
+//
+//	db.Connect(string(cfg.Password))
+type Secret string
+
+// String returns REDACTED instead of the secret
+func (Secret) String() string {
+	return redacted
+}
+
+// GoString returns REDACTED instead of the secret
+func (Secret) GoString() string {
+	return redacted
+}
+
+// LogValue returns REDACTED instead of the secret, see slog.LogValuer
+func (Secret) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
+
+var secretType = reflect.TypeOf(Secret(""))
+
+func init() {
+	RegisterType(secretType, TypeHandler{
+		Type: cty.String,
+		Name: "secret",
+		Decode: func(val cty.Value) (interface{}, error) {
+			return Secret(val.AsString()), nil
+		},
+		Encode: func(v interface{}) (cty.Value, error) {
+			return cty.StringVal(string(v.(Secret))).Mark(SecretMark), nil
+		},
+	})
+}
+
+// isSecretType returns whether the type is Secret or a pointer to it
+func isSecretType(ty reflect.Type) bool {
+	return ty == secretType || ty == reflect.PtrTo(secretType)
+}
+
+// unmarkSecret removes the secret mark of the value of the expression decoded into the target type, which is reported
+// as an error unless the type is a Secret
+func unmarkSecret(val cty.Value, ty reflect.Type, expr hcl.Expression) (cty.Value, hcl.Diagnostics) {
+	if !val.ContainsMarked() {
+		return val, nil
+	}
+
+	val, marks := val.UnmarkDeep()
+	if _, ok := marks[SecretMark]; ok && !isSecretType(ty) {
+		return cty.DynamicVal, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Unsuitable secret value",
+			Detail:   "The value is a secret, which can only be used for attributes of type secret so it is never logged or written.",
+			Subject:  expr.StartRange().Ptr(),
+			Context:  expr.Range().Ptr(),
+		}}
+	}
+
+	return val, nil
+}
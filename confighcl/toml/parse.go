@@ -0,0 +1,418 @@
+// Package toml parses TOML configuration files into an hcl.File, so they can be decoded by confighcl the same as HCL
+// native and JSON files.
+//
+// Tables and inline tables are objects whose keys are arguments or blocks depending on the schema, block labels are
+// nested tables and arrays of tables are multiple blocks. Dates and times are decoded as strings, and strings are
+// evaluated as HCL templates.
+package toml
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+	"github.com/portcullis/application/confighcl/internal/tree"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Parse the TOML source of the file
+func Parse(src []byte, filename string) (*hcl.File, hcl.Diagnostics) {
+	p := &parser{
+		source:  tree.NewSource(filename, src),
+		defined: map[*tree.Object]bool{},
+		frozen:  map[*tree.Object]bool{},
+	}
+	p.Reset(src)
+
+	root := &tree.Object{SrcRange: p.source.Range(p.source.PosAtByte(0), p.source.PosAtByte(len(src)))}
+	current := root
+
+	var diags hcl.Diagnostics
+	for p.NextExpression() {
+		expr := p.Expression()
+
+		switch expr.Kind {
+		case unstable.KeyValue:
+			diags = append(diags, p.keyValue(current, expr)...)
+		case unstable.Table:
+			var tableDiags hcl.Diagnostics
+			current, tableDiags = p.table(root, expr.Key())
+			diags = append(diags, tableDiags...)
+		case unstable.ArrayTable:
+			var tableDiags hcl.Diagnostics
+			current, tableDiags = p.arrayTable(root, expr.Key())
+			diags = append(diags, tableDiags...)
+		}
+
+		if current == nil {
+			// continue parsing into a detached table, to report the remaining errors of the file
+			current = &tree.Object{}
+		}
+	}
+
+	if err := p.Error(); err != nil {
+		diags = append(diags, p.parseError(err))
+	}
+
+	extendRanges(root)
+
+	return tree.NewFile(root, src), diags
+}
+
+// parser tracks the tables of the document while parsing the expressions
+type parser struct {
+	unstable.Parser
+
+	source *tree.Source
+
+	// defined are the tables that have been defined by a header, which cannot be defined again
+	defined map[*tree.Object]bool
+
+	// frozen are the inline tables, which cannot be extended after their definition
+	frozen map[*tree.Object]bool
+}
+
+// keyValue sets the value of the dotted key relative to the table
+func (p *parser) keyValue(table *tree.Object, expr *unstable.Node) hcl.Diagnostics {
+	keys := p.keys(expr.Key())
+
+	obj, diags := p.descend(table, keys[:len(keys)-1], false)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	last := keys[len(keys)-1]
+	if existing := obj.Get(last.name); existing != nil {
+		return append(diags, p.duplicate(last, existing))
+	}
+
+	value, valueDiags := p.value(expr.Value(), last.rng)
+	diags = append(diags, valueDiags...)
+
+	obj.Attrs = append(obj.Attrs, &tree.Attr{Name: last.name, NameRange: last.rng, Value: value})
+
+	return diags
+}
+
+// table defines the table of the header, returning the object its keys are added to
+func (p *parser) table(root *tree.Object, it unstable.Iterator) (*tree.Object, hcl.Diagnostics) {
+	keys := p.keys(it)
+
+	obj, diags := p.descend(root, keys, true)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	if p.defined[obj] {
+		last := keys[len(keys)-1]
+		return nil, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Duplicate table",
+			Detail:   fmt.Sprintf("The table %q was already defined at %s.", joinKeys(keys), obj.SrcRange),
+			Subject:  last.rng.Ptr(),
+		})
+	}
+
+	p.defined[obj] = true
+	obj.SrcRange = hcl.RangeBetween(keys[0].rng, keys[len(keys)-1].rng)
+
+	return obj, diags
+}
+
+// arrayTable appends a table to the array of the header, returning the object its keys are added to
+func (p *parser) arrayTable(root *tree.Object, it unstable.Iterator) (*tree.Object, hcl.Diagnostics) {
+	keys := p.keys(it)
+
+	parent, diags := p.descend(root, keys[:len(keys)-1], true)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	last := keys[len(keys)-1]
+	headerRange := hcl.RangeBetween(keys[0].rng, last.rng)
+	obj := &tree.Object{SrcRange: headerRange}
+	p.defined[obj] = true
+
+	existing := parent.Get(last.name)
+	if existing == nil {
+		parent.Attrs = append(parent.Attrs, &tree.Attr{
+			Name:      last.name,
+			NameRange: last.rng,
+			Value:     &tree.Array{Values: []tree.Node{obj}, SrcRange: headerRange},
+		})
+
+		return obj, diags
+	}
+
+	array, ok := existing.Value.(*tree.Array)
+	if !ok || p.frozen[parent] || !p.tables(array) {
+		return nil, append(diags, p.duplicate(last, existing))
+	}
+
+	array.Values = append(array.Values, obj)
+
+	return obj, diags
+}
+
+// descend returns the object of the dotted keys relative to the table, implicitly creating the missing tables. The
+// last table of an array of tables is used when arrays are allowed, as done by table headers.
+func (p *parser) descend(table *tree.Object, keys []key, arrays bool) (*tree.Object, hcl.Diagnostics) {
+	obj := table
+	for _, k := range keys {
+		if p.frozen[obj] {
+			return nil, hcl.Diagnostics{p.immutable(k)}
+		}
+
+		attr := obj.Get(k.name)
+		if attr == nil {
+			child := &tree.Object{SrcRange: k.rng}
+			obj.Attrs = append(obj.Attrs, &tree.Attr{Name: k.name, NameRange: k.rng, Value: child})
+			obj = child
+			continue
+		}
+
+		switch v := attr.Value.(type) {
+		case *tree.Object:
+			// key/values cannot extend a table defined by a header
+			if !arrays && p.defined[v] {
+				return nil, hcl.Diagnostics{p.duplicate(k, attr)}
+			}
+
+			obj = v
+			continue
+		case *tree.Array:
+			if arrays && p.tables(v) && len(v.Values) > 0 {
+				obj = v.Values[len(v.Values)-1].(*tree.Object)
+				continue
+			}
+		}
+
+		return nil, hcl.Diagnostics{p.duplicate(k, attr)}
+	}
+
+	if p.frozen[obj] && len(keys) > 0 {
+		return nil, hcl.Diagnostics{p.immutable(keys[len(keys)-1])}
+	}
+
+	return obj, nil
+}
+
+// tables returns whether the array was defined by array table headers
+func (p *parser) tables(array *tree.Array) bool {
+	for _, v := range array.Values {
+		if obj, ok := v.(*tree.Object); !ok || !p.defined[obj] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// value converts the value node, rng is the range used for values without a position
+func (p *parser) value(n *unstable.Node, rng hcl.Range) (tree.Node, hcl.Diagnostics) {
+	switch n.Kind {
+	case unstable.InlineTable:
+		obj := &tree.Object{SrcRange: p.rawRange(n.Raw)}
+
+		var diags hcl.Diagnostics
+		for it := n.Children(); it.Next(); {
+			diags = append(diags, p.keyValue(obj, it.Node())...)
+		}
+		p.frozen[obj] = true
+
+		return obj, diags
+
+	case unstable.Array:
+		array := &tree.Array{}
+
+		var diags hcl.Diagnostics
+		for it := n.Children(); it.Next(); {
+			value, valueDiags := p.value(it.Node(), rng)
+			array.Values = append(array.Values, value)
+			diags = append(diags, valueDiags...)
+		}
+
+		array.SrcRange = rng
+		if len(array.Values) > 0 {
+			array.SrcRange = hcl.RangeBetween(array.Values[0].Range(), array.Values[len(array.Values)-1].Range())
+		}
+
+		return array, diags
+
+	case unstable.String:
+		r := p.rawRange(n.Raw)
+
+		// skip the opening quotes, the template positions are approximate when the string contains escapes
+		raw := p.Raw(n.Raw)
+		quotes := 1
+		if len(raw) >= 6 && (strings.HasPrefix(string(raw), `"""`) || strings.HasPrefix(string(raw), `'''`)) {
+			quotes = 3
+		}
+
+		return &tree.Scalar{
+			Value:         cty.StringVal(string(n.Data)),
+			SrcRange:      r,
+			TemplateStart: p.source.PosAtByte(r.Start.Byte + quotes),
+		}, nil
+	}
+
+	r := p.dataRange(n.Data, rng)
+	s := string(n.Data)
+
+	var val cty.Value
+	switch n.Kind {
+	case unstable.Bool:
+		val = cty.BoolVal(s == "true")
+
+	case unstable.Integer:
+		i, err := strconv.ParseInt(s, 0, 64)
+		if err != nil {
+			return &tree.Scalar{Value: cty.DynamicVal, SrcRange: r}, hcl.Diagnostics{p.invalidNumber(s, r, err)}
+		}
+
+		val = cty.NumberIntVal(i)
+
+	case unstable.Float:
+		s = strings.ReplaceAll(s, "_", "")
+		if strings.HasSuffix(s, "nan") {
+			return &tree.Scalar{Value: cty.DynamicVal, SrcRange: r}, hcl.Diagnostics{p.invalidNumber(s, r, fmt.Errorf("NaN is not supported"))}
+		}
+
+		if strings.HasSuffix(s, "inf") {
+			val = cty.NumberFloatVal(math.Inf(1))
+			if strings.HasPrefix(s, "-") {
+				val = cty.NumberFloatVal(math.Inf(-1))
+			}
+			break
+		}
+
+		var err error
+		if val, err = cty.ParseNumberVal(strings.TrimPrefix(s, "+")); err != nil {
+			return &tree.Scalar{Value: cty.DynamicVal, SrcRange: r}, hcl.Diagnostics{p.invalidNumber(s, r, err)}
+		}
+
+	default:
+		// dates and times are represented in their RFC 3339 form
+		val = cty.StringVal(s)
+	}
+
+	return &tree.Scalar{Value: val, SrcRange: r, TemplateStart: r.Start}, nil
+}
+
+// key is a simple key of a dotted key
+type key struct {
+	name string
+	rng  hcl.Range
+}
+
+// keys returns the simple keys of the key iterator
+func (p *parser) keys(it unstable.Iterator) []key {
+	var keys []key
+	for it.Next() {
+		n := it.Node()
+		keys = append(keys, key{name: string(n.Data), rng: p.rawRange(n.Raw)})
+	}
+
+	return keys
+}
+
+// joinKeys returns the dotted key
+func joinKeys(keys []key) string {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k.name
+	}
+
+	return strings.Join(names, ".")
+}
+
+// rawRange returns the source range of the raw bytes
+func (p *parser) rawRange(raw unstable.Range) hcl.Range {
+	return p.source.Range(p.source.PosAtByte(int(raw.Offset)), p.source.PosAtByte(int(raw.Offset+raw.Length)))
+}
+
+// dataRange returns the source range of value data referencing the input, or fallback when it is not available
+func (p *parser) dataRange(data []byte, fallback hcl.Range) (r hcl.Range) {
+	defer func() {
+		// the data is allocated when it does not reference the input
+		if recover() != nil {
+			r = fallback
+		}
+	}()
+
+	return p.rawRange(p.Range(data))
+}
+
+// duplicate returns the diagnostic for a key that was already defined
+func (p *parser) duplicate(k key, existing *tree.Attr) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Duplicate key",
+		Detail:   fmt.Sprintf("The key %q was already defined at %s.", k.name, existing.NameRange),
+		Subject:  k.rng.Ptr(),
+	}
+}
+
+// immutable returns the diagnostic for keys extending an inline table
+func (p *parser) immutable(k key) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid key",
+		Detail:   fmt.Sprintf("The key %q extends an inline table, which must be fully defined where it is declared.", k.name),
+		Subject:  k.rng.Ptr(),
+	}
+}
+
+// invalidNumber returns the diagnostic for a number that cannot be represented
+func (p *parser) invalidNumber(s string, r hcl.Range, err error) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid number",
+		Detail:   fmt.Sprintf("The number %s cannot be used: %s.", s, err),
+		Subject:  r.Ptr(),
+	}
+}
+
+// parseError returns the diagnostic of a syntax error, positioned at the highlighted bytes when available
+func (p *parser) parseError(err error) *hcl.Diagnostic {
+	diag := &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid TOML syntax",
+		Detail:   err.Error(),
+	}
+
+	if perr, ok := err.(*unstable.ParserError); ok {
+		diag.Detail = perr.Message
+
+		end := p.source.PosAtByte(len(p.Data()))
+		r := p.dataRange(perr.Highlight, p.source.Range(end, end))
+		diag.Subject = &r
+	}
+
+	return diag
+}
+
+// extendRanges extends the range of the tables to include their keys
+func extendRanges(n tree.Node) hcl.Range {
+	switch v := n.(type) {
+	case *tree.Object:
+		for _, attr := range v.Attrs {
+			r := extendRanges(attr.Value)
+			if v.SrcRange.Filename == "" {
+				v.SrcRange = r
+			}
+			v.SrcRange = hcl.RangeOver(v.SrcRange, hcl.RangeOver(attr.NameRange, r))
+		}
+		return v.SrcRange
+	case *tree.Array:
+		for _, av := range v.Values {
+			v.SrcRange = hcl.RangeOver(v.SrcRange, extendRanges(av))
+		}
+		return v.SrcRange
+	}
+
+	return n.Range()
+}
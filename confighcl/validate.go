@@ -0,0 +1,285 @@
+package confighcl
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// validationRule is a rule of a validate tag
+type validationRule struct {
+	name string
+	arg  string
+
+	// regex is the compiled argument of the regex rule
+	regex *regexp.Regexp
+}
+
+// parseValidationRules parses the validate tag of the field, panicking on invalid rules since they are bugs in the
+// calling program
+func parseValidationRules(tag string, field reflect.StructField) []validationRule {
+	var rules []validationRule
+	for tag != "" {
+		var option string
+		if strings.HasPrefix(tag, "regex=") {
+			// the regex rule is last, so the expression can contain commas
+			option, tag = tag, ""
+		} else if comma := strings.Index(tag, ","); comma != -1 {
+			option, tag = tag[:comma], tag[comma+1:]
+		} else {
+			option, tag = tag, ""
+		}
+
+		name, arg, _ := strings.Cut(option, "=")
+		rule := validationRule{name: name, arg: arg}
+
+		invalid := func(reason string) {
+			panic(fmt.Sprintf("invalid validate tag rule %q on %s %q: %s", option, field.Type.String(), field.Name, reason))
+		}
+
+		switch name {
+		case "nonempty", "port", "url":
+			if arg != "" {
+				invalid("no argument is allowed")
+			}
+		case "min", "max":
+			zero := reflect.New(indirectType(field.Type)).Elem()
+			if _, isLength := length(zero); !isLength {
+				if _, isNumber := number(zero); !isNumber {
+					invalid("only numbers, strings, lists and maps are supported")
+				}
+			}
+			if _, ok := limitValue(field.Type, arg); !ok {
+				invalid("the argument must be a number, or a duration for durations")
+			}
+		case "oneof":
+			if arg == "" {
+				invalid("at least one value is required")
+			}
+		case "regex":
+			var err error
+			if rule.regex, err = regexp.Compile(arg); err != nil {
+				invalid(err.Error())
+			}
+		case "required_if":
+			if other, _, _ := strings.Cut(arg, " "); other == "" {
+				invalid("an attribute name and value are required")
+			}
+		default:
+			invalid("unknown rule")
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// validateStruct applies the validate tags of the attributes of the decoded struct, attributes that are not set or
+// defaulted are only validated by required_if
+func validateStruct(val reflect.Value, tags *fieldTags, attrs hcl.Attributes, missing hcl.Range) hcl.Diagnostics {
+	names := make([]string, 0, len(tags.Validate))
+	for name := range tags.Validate {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var diags hcl.Diagnostics
+	for _, name := range names {
+		var rng *hcl.Range
+		if attr, set := attrs[name]; set {
+			rng = attr.Expr.Range().Ptr()
+		} else if _, hasDefault := tags.Defaults[name]; hasDefault {
+			rng = missing.Ptr()
+		}
+
+		diags = append(diags, validateAttribute(val, tags, name, rng, missing)...)
+	}
+
+	return diags
+}
+
+// validateAttribute applies the validate tag of the named attribute of the struct, rng is the range of the value or nil
+// when it is not set
+func validateAttribute(val reflect.Value, tags *fieldTags, name string, rng *hcl.Range, missing hcl.Range) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	fieldV := val.Field(tags.Attributes[name])
+
+	for _, rule := range tags.Validate[name] {
+		if rule.name == "required_if" {
+			other, expected, _ := strings.Cut(rule.arg, " ")
+			idx, found := tags.Attributes[other]
+			if rng != nil || !found {
+				continue
+			}
+
+			// an absent optional attribute does not match
+			otherV := indirect(val.Field(idx))
+			if !otherV.IsValid() || fmt.Sprint(otherV.Interface()) != expected {
+				continue
+			}
+
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Missing required argument",
+				Detail:   fmt.Sprintf("The argument %q is required when %s is %s.", name, other, expected),
+				Subject:  missing.Ptr(),
+			})
+			continue
+		}
+
+		v := indirect(fieldV)
+		if rng == nil || !v.IsValid() {
+			continue
+		}
+
+		if detail := rule.check(v); detail != "" {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid value",
+				Detail:   fmt.Sprintf("The value of %s %s.", name, detail),
+				Subject:  rng,
+			})
+
+			// the remaining rules are likely to fail for the same reason
+			break
+		}
+	}
+
+	return diags
+}
+
+// check the value against the rule, returning the reason it is invalid or empty when it is valid
+func (r validationRule) check(v reflect.Value) string {
+	switch r.name {
+	case "nonempty":
+		if n, ok := length(v); ok && n == 0 {
+			return "must not be empty"
+		}
+
+	case "min", "max":
+		limit, _ := limitValue(v.Type(), r.arg)
+		actual, isLength := length(v)
+		if !isLength {
+			actual, _ = number(v)
+		}
+
+		noun := "at least"
+		if r.name == "max" {
+			noun = "at most"
+		}
+
+		if (r.name == "min" && actual < limit) || (r.name == "max" && actual > limit) {
+			if isLength {
+				return fmt.Sprintf("must have a length of %s %s", noun, r.arg)
+			}
+			return fmt.Sprintf("must be %s %s", noun, r.arg)
+		}
+
+	case "oneof":
+		options := strings.Fields(r.arg)
+		actual := fmt.Sprint(v.Interface())
+		for _, option := range options {
+			if actual == option {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of %s", strings.Join(options, ", "))
+
+	case "regex":
+		if !r.regex.MatchString(fmt.Sprint(v.Interface())) {
+			return fmt.Sprintf("must match the regular expression %s", r.arg)
+		}
+
+	case "port":
+		port := fmt.Sprint(v.Interface())
+		if v.Kind() == reflect.String {
+			if _, p, err := net.SplitHostPort(port); err == nil {
+				port = p
+			}
+		}
+
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return "must be a port between 1 and 65535"
+		}
+
+	case "url":
+		if u, err := url.Parse(fmt.Sprint(v.Interface())); err != nil || u.Scheme == "" || u.Host == "" {
+			return "must be an absolute URL"
+		}
+	}
+
+	return ""
+}
+
+// indirect dereferences pointers, returning an invalid value for nil pointers
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+
+	return v
+}
+
+// length of strings in characters, slices and maps
+func length(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	}
+
+	return 0, false
+}
+
+// limitValue parses the argument of the min and max rules for the type, durations and byte sizes are parsed as such and
+// compared in nanoseconds and bytes
+func limitValue(ty reflect.Type, arg string) (float64, bool) {
+	switch indirectType(ty) {
+	case durationType:
+		d, err := time.ParseDuration(arg)
+		return float64(d), err == nil
+	case reflect.TypeOf(ByteSize(0)):
+		b, err := ParseByteSize(arg)
+		return float64(b), err == nil
+	}
+
+	f, err := strconv.ParseFloat(arg, 64)
+	return f, err == nil
+}
+
+// number returns the value of integers and floats
+func number(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+
+	return 0, false
+}
+
+// indirectType dereferences pointer types
+func indirectType(ty reflect.Type) reflect.Type {
+	for ty.Kind() == reflect.Ptr {
+		ty = ty.Elem()
+	}
+
+	return ty
+}
@@ -0,0 +1,151 @@
+package confighcl
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// EncodeValue returns the given struct value, or pointer to a struct value, with the struct tags defined in this
+// package as an object of its attributes, labels and blocks, which expressions of other configurations can refer to.
+//
+// Attributes are encoded like EncodeIntoBody encodes them and are null when they are nil pointers. Single blocks are
+// objects, or null when they are absent, and blocks that may be repeated are tuples of objects. It has the same
+// constraints as EncodeIntoBody and will panic if they are violated.
+func EncodeValue(val interface{}) cty.Value {
+	rv := reflect.ValueOf(val)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return cty.NullVal(cty.DynamicPseudoType)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("value is %s, not struct", rv.Kind()))
+	}
+
+	ty := rv.Type()
+	tags := getFieldTags(ty)
+
+	attrs := make(map[string]cty.Value, len(tags.Attributes)+len(tags.Labels)+len(tags.Blocks))
+	for _, lf := range tags.Labels {
+		attrs[lf.Name] = cty.StringVal(fmt.Sprintf("%s", rv.Field(lf.FieldIndex).Interface()))
+	}
+
+	for name, fieldIdx := range tags.Attributes {
+		fieldTy := ty.Field(fieldIdx).Type
+		if exprType.AssignableTo(fieldTy) || attrType.AssignableTo(fieldTy) {
+			continue // ignore undecoded fields
+		}
+
+		fieldVal := rv.Field(fieldIdx)
+		if fieldVal.Kind() == reflect.Ptr && fieldVal.IsNil() {
+			// registered pointer types encode nil pointers themselves
+			if _, ok := typeHandler(fieldTy); !ok {
+				attrs[name] = cty.NullVal(cty.DynamicPseudoType)
+				continue
+			}
+		}
+		attrs[name] = attributeValue(fieldVal)
+	}
+
+	for name, fieldIdx := range tags.Blocks {
+		fieldTy := ty.Field(fieldIdx).Type
+		fieldVal := rv.Field(fieldIdx)
+
+		elemTy := fieldTy
+		isSeq := elemTy.Kind() == reflect.Slice || elemTy.Kind() == reflect.Array
+		if isSeq {
+			elemTy = elemTy.Elem()
+		}
+		if bodyType.AssignableTo(elemTy) || attrsType.AssignableTo(elemTy) || blockType.AssignableTo(elemTy) {
+			continue // ignore undecoded fields
+		}
+
+		if fieldVal.Kind() == reflect.Ptr {
+			if fieldVal.IsNil() {
+				attrs[name] = cty.NullVal(cty.DynamicPseudoType)
+				continue
+			}
+			fieldVal = fieldVal.Elem()
+		}
+
+		if !isSeq {
+			attrs[name] = blockValue(fieldVal)
+			continue
+		}
+
+		elems := make([]cty.Value, 0, fieldVal.Len())
+		for i := 0; i < fieldVal.Len(); i++ {
+			elems = append(elems, blockValue(fieldVal.Index(i)))
+		}
+		attrs[name] = cty.TupleVal(elems)
+	}
+
+	return cty.ObjectVal(attrs)
+}
+
+// blockValue encodes the value decoded from a block, a struct, a pointer to one or a map of attributes
+func blockValue(rv reflect.Value) cty.Value {
+	if rv.Kind() == reflect.Map {
+		return attributeValue(rv)
+	}
+
+	return EncodeValue(rv.Interface())
+}
+
+// Variables returns the variables referenced by the expressions of the attributes of the body that DecodeBody would
+// decode into the given struct value, or pointer to a struct value, including those of its nested blocks. Blocks
+// decoded into hcl.Body values and the remaining body are not inspected.
+func Variables(body hcl.Body, val interface{}) []hcl.Traversal {
+	ty := reflect.TypeOf(val)
+	if ty.Kind() == reflect.Ptr {
+		ty = ty.Elem()
+	}
+
+	return structVariables(body, ty)
+}
+
+// structVariables returns the variables referenced by the body decoded into the struct type
+func structVariables(body hcl.Body, ty reflect.Type) []hcl.Traversal {
+	schema, _ := ImpliedBodySchema(reflect.New(ty).Interface())
+	content, _, _ := body.PartialContent(schema)
+	if content == nil {
+		return nil
+	}
+
+	tags := getFieldTags(ty)
+
+	names := make([]string, 0, len(content.Attributes))
+	for name := range content.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var traversals []hcl.Traversal
+	for _, name := range names {
+		traversals = append(traversals, content.Attributes[name].Expr.Variables()...)
+	}
+
+	for _, block := range content.Blocks {
+		elemTy := ty.Field(tags.Blocks[block.Type]).Type
+		for elemTy.Kind() == reflect.Ptr || elemTy.Kind() == reflect.Slice || elemTy.Kind() == reflect.Array {
+			elemTy = elemTy.Elem()
+		}
+
+		switch elemTy.Kind() {
+		case reflect.Struct:
+			traversals = append(traversals, structVariables(block.Body, elemTy)...)
+		case reflect.Map:
+			attrs, _ := block.Body.JustAttributes()
+			for _, attr := range attrs {
+				traversals = append(traversals, attr.Expr.Variables()...)
+			}
+		}
+	}
+
+	return traversals
+}
@@ -0,0 +1,288 @@
+// Package yaml parses YAML configuration files into an hcl.File, so they can be decoded by confighcl the same as HCL
+// native and JSON files.
+//
+// Mappings are objects whose keys are arguments or blocks depending on the schema, block labels are nested mappings and
+// sequences of mappings are multiple blocks. Anchors, aliases and merge keys are resolved, timestamps are decoded as
+// strings, and strings are evaluated as HCL templates.
+package yaml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/portcullis/application/confighcl/internal/tree"
+	"github.com/zclconf/go-cty/cty"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// errorLine matches the line reported by syntax errors
+var errorLine = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// Parse the YAML source of the file
+func Parse(src []byte, filename string) (*hcl.File, hcl.Diagnostics) {
+	source := tree.NewSource(filename, src)
+	fileRange := source.Range(source.PosAtByte(0), source.PosAtByte(len(src)))
+
+	var doc yamlv3.Node
+	decoder := yamlv3.NewDecoder(bytes.NewReader(src))
+	if err := decoder.Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			// an empty document has no configuration
+			return tree.NewFile(&tree.Object{SrcRange: fileRange}, src), nil
+		}
+
+		return nil, hcl.Diagnostics{syntaxError(source, err)}
+	}
+
+	var extra yamlv3.Node
+	if err := decoder.Decode(&extra); !errors.Is(err, io.EOF) {
+		diag := &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Multiple YAML documents",
+			Detail:   "A configuration file must contain a single YAML document.",
+		}
+		if err == nil {
+			r := source.Range(source.PosAt(extra.Line, extra.Column), source.PosAt(extra.Line, extra.Column))
+			diag.Subject = &r
+		} else {
+			diag = syntaxError(source, err)
+		}
+
+		return nil, hcl.Diagnostics{diag}
+	}
+
+	p := &parser{source: source}
+	root, diags := p.node(doc.Content[0])
+
+	switch v := root.(type) {
+	case *tree.Object:
+		v.SrcRange = fileRange
+		return tree.NewFile(v, src), diags
+	case *tree.Scalar:
+		// a document with only comments is null
+		if v.Value.IsNull() {
+			return tree.NewFile(&tree.Object{SrcRange: fileRange}, src), diags
+		}
+	}
+
+	return nil, append(diags, &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Incorrect value type",
+		Detail:   "The YAML document must be a mapping, defining the arguments and blocks of the configuration.",
+		Subject:  root.StartRange().Ptr(),
+	})
+}
+
+// parser converts the nodes of a document
+type parser struct {
+	source *tree.Source
+}
+
+// node converts the YAML node
+func (p *parser) node(n *yamlv3.Node) (tree.Node, hcl.Diagnostics) {
+	switch n.Kind {
+	case yamlv3.AliasNode:
+		return p.node(n.Alias)
+	case yamlv3.MappingNode:
+		return p.mapping(n)
+	case yamlv3.SequenceNode:
+		array := &tree.Array{SrcRange: p.rangeOf(n)}
+
+		var diags hcl.Diagnostics
+		for _, child := range n.Content {
+			value, valueDiags := p.node(child)
+			array.Values = append(array.Values, value)
+			array.SrcRange = hcl.RangeOver(array.SrcRange, value.Range())
+			diags = append(diags, valueDiags...)
+		}
+
+		return array, diags
+	}
+
+	return p.scalar(n)
+}
+
+// mapping converts a mapping node to an object, merging the mappings of merge keys
+func (p *parser) mapping(n *yamlv3.Node) (tree.Node, hcl.Diagnostics) {
+	obj := &tree.Object{SrcRange: p.rangeOf(n)}
+
+	var diags hcl.Diagnostics
+	var merges []*tree.Object
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+
+		value, valueDiags := p.node(v)
+		diags = append(diags, valueDiags...)
+		obj.SrcRange = hcl.RangeOver(obj.SrcRange, value.Range())
+
+		if k.Kind != yamlv3.ScalarNode {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid key",
+				Detail:   "Mapping keys must be strings, to name the arguments and blocks of the configuration.",
+				Subject:  p.rangeOf(k).Ptr(),
+			})
+			continue
+		}
+
+		if k.ShortTag() == "!!merge" {
+			merged, mergeDiags := p.merges(value)
+			merges = append(merges, merged...)
+			diags = append(diags, mergeDiags...)
+			continue
+		}
+
+		nameRange := p.rangeOf(k)
+		if existing := obj.Get(k.Value); existing != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate key",
+				Detail:   fmt.Sprintf("The key %q was already defined at %s.", k.Value, existing.NameRange),
+				Subject:  nameRange.Ptr(),
+			})
+			continue
+		}
+
+		obj.Attrs = append(obj.Attrs, &tree.Attr{Name: k.Value, NameRange: nameRange, Value: value})
+	}
+
+	// explicit keys override merged keys, and earlier merged mappings override later ones
+	for _, merged := range merges {
+		for _, attr := range merged.Attrs {
+			if obj.Get(attr.Name) == nil {
+				obj.Attrs = append(obj.Attrs, attr)
+			}
+		}
+	}
+
+	return obj, diags
+}
+
+// merges returns the mappings of a merge key value, which is a mapping or a sequence of mappings
+func (p *parser) merges(value tree.Node) ([]*tree.Object, hcl.Diagnostics) {
+	values := []tree.Node{value}
+	if array, ok := value.(*tree.Array); ok {
+		values = array.Values
+	}
+
+	var merged []*tree.Object
+	for _, v := range values {
+		obj, ok := v.(*tree.Object)
+		if !ok {
+			return nil, hcl.Diagnostics{{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid merge",
+				Detail:   "A merge key requires a mapping or a sequence of mappings.",
+				Subject:  v.StartRange().Ptr(),
+			}}
+		}
+
+		merged = append(merged, obj)
+	}
+
+	return merged, nil
+}
+
+// scalar converts a scalar node according to its resolved tag
+func (p *parser) scalar(n *yamlv3.Node) (tree.Node, hcl.Diagnostics) {
+	scalar := &tree.Scalar{Value: cty.DynamicVal, SrcRange: p.rangeOf(n)}
+
+	scalar.TemplateStart = scalar.SrcRange.Start
+	if n.Style&(yamlv3.DoubleQuotedStyle|yamlv3.SingleQuotedStyle) != 0 {
+		scalar.TemplateStart = p.source.Advance(scalar.TemplateStart, 1)
+	}
+
+	var value interface{}
+	if err := n.Decode(&value); err != nil {
+		return scalar, hcl.Diagnostics{p.invalidValue(n, err)}
+	}
+
+	switch tag := n.ShortTag(); tag {
+	case "!!null":
+		scalar.Value = cty.NullVal(cty.DynamicPseudoType)
+	case "!!bool":
+		b, _ := value.(bool)
+		scalar.Value = cty.BoolVal(b)
+	case "!!int", "!!float":
+		// prefer the exact decimal representation of the source over the decoded float
+		if num, err := cty.ParseNumberVal(n.Value); err == nil {
+			scalar.Value = num
+			break
+		}
+
+		switch v := value.(type) {
+		case int:
+			scalar.Value = cty.NumberIntVal(int64(v))
+		case int64:
+			scalar.Value = cty.NumberIntVal(v)
+		case uint64:
+			scalar.Value = cty.NumberUIntVal(v)
+		case float64:
+			if math.IsNaN(v) {
+				return scalar, hcl.Diagnostics{p.invalidValue(n, errors.New("NaN is not supported"))}
+			}
+			scalar.Value = cty.NumberFloatVal(v)
+		default:
+			return scalar, hcl.Diagnostics{p.invalidValue(n, fmt.Errorf("cannot decode %s", tag))}
+		}
+	default:
+		// strings, timestamps, binary and custom tags are represented by their source value
+		scalar.Value = cty.StringVal(n.Value)
+	}
+
+	return scalar, nil
+}
+
+// rangeOf returns the range of the node, which ends with the value of scalars on the same line
+func (p *parser) rangeOf(n *yamlv3.Node) hcl.Range {
+	start := p.source.PosAt(n.Line, n.Column)
+
+	length := 0
+	switch {
+	case n.Kind != yamlv3.ScalarNode:
+	case n.Style&(yamlv3.LiteralStyle|yamlv3.FoldedStyle) != 0:
+		length = 1
+	case n.Style&(yamlv3.DoubleQuotedStyle|yamlv3.SingleQuotedStyle) != 0:
+		length = utf8.RuneCountInString(n.Value) + 2
+	default:
+		length = utf8.RuneCountInString(n.Value)
+	}
+
+	return p.source.Range(start, p.source.Advance(start, length))
+}
+
+// invalidValue returns the diagnostic for a scalar that cannot be decoded
+func (p *parser) invalidValue(n *yamlv3.Node, err error) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid value",
+		Detail:   fmt.Sprintf("The value %q cannot be used: %s.", n.Value, err),
+		Subject:  p.rangeOf(n).Ptr(),
+	}
+}
+
+// syntaxError returns the diagnostic of a YAML error, positioned at the start of the reported line
+func syntaxError(source *tree.Source, err error) *hcl.Diagnostic {
+	diag := &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid YAML syntax",
+		Detail:   err.Error(),
+	}
+
+	if m := errorLine.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		diag.Detail = err.Error()[len(m[0]):]
+
+		r := source.Range(source.PosAt(line, 1), source.PosAt(line, 1))
+		diag.Subject = &r
+	}
+
+	return diag
+}
@@ -1,18 +1,24 @@
 package application
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/hashicorp/hcl/v2/json"
 	"github.com/portcullis/application/confighcl"
 	"github.com/portcullis/application/confighcl/funcs"
+	"github.com/portcullis/application/confighcl/toml"
+	"github.com/portcullis/application/confighcl/yaml"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -23,35 +29,174 @@ var (
 )
 
 // Configuration for the application based on github.com/hashicorp/hcl/v2
-type Configuration struct{}
+//
+// Besides the configuration of the modules, the top level of the configuration may declare variables and local values
+// that the expressions of every module refer to as var.name and local.name:
+//
+//	variable "region" {
+//	  default     = "eu-west-1"
+//	  description = "The region to deploy to"
+//	}
+//
+//	locals {
+//	  bucket = "assets-${var.region}"
+//	}
+//
+// Variables are read from environment variables such as APP_VAR_REGION when environment variable overrides are enabled,
+// and local values may refer to each other. The expressions of a module may also refer to the decoded configuration of
+// another module as module.name, or module["name"] for names that are not identifiers, such as module.http.listen_addr.
+// The module is then decoded first, and references in a cycle are reported as errors.
+type Configuration struct {
+	// defaults holds a copy of each module configuration before it was first decoded, reloads decode into a copy of these
+	defaults map[string]reflect.Value
+
+	// env enables overriding the decoded module configurations with environment variables named after envPrefix
+	env       bool
+	envPrefix string
+
+	// flags are the overrides parsed from the command line by ParseFlags for each module, applied in order
+	flags map[string][]confighcl.FieldValue
+
+	// args are the command line arguments remaining after the flags
+	args []string
+
+	// blocks scopes the configuration of each module to a block named by the module instead of the top level
+	blocks bool
+
+	// allowUnknown reports unknown arguments and blocks as warnings instead of errors
+	allowUnknown bool
+
+	// secrets are the providers resolving secrets by the name of their function
+	secrets map[string]SecretProvider
+
+	// files are the parsed files by filename, holding the sources the diagnostics refer to
+	files map[string]*hcl.File
+}
 
 // DecodeFile will open and decode the provided file, returning an error when parsing fails
-func (c Configuration) DecodeFile(ctx context.Context, filename string) hcl.Diagnostics {
-	src, err := os.ReadFile(filename)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return hcl.Diagnostics{
-				{
+func (c *Configuration) DecodeFile(ctx context.Context, filename string) hcl.Diagnostics {
+	src, diags := readFile(filename)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	return c.Decode(ctx, filename, src)
+}
+
+// DecodeFiles will open and decode the provided files and directories merged in order, returning an error when parsing fails
+func (c *Configuration) DecodeFiles(ctx context.Context, paths ...string) hcl.Diagnostics {
+	body, diags := c.ParseFiles(paths...)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	return c.DecodeBody(ctx, body)
+}
+
+// Decode the provided source into the Configurable modules of the application in the context
+func (c *Configuration) Decode(ctx context.Context, filename string, src []byte) hcl.Diagnostics {
+	file, diags := c.Parse(filename, src)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	return c.DecodeBody(ctx, file.Body)
+}
+
+// DecodeBody decodes the body into the Configurable modules of the application in the context
+func (c *Configuration) DecodeBody(ctx context.Context, body hcl.Body) hcl.Diagnostics {
+	configs, diags := c.decodeModules(ctx, body, false)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	for _, cfg := range configs {
+		if notifier, ok := cfg.module.(ConfigurableNotify); ok {
+			if err := notifier.ConfigSet(cfg.value); err != nil {
+				// diagnostics carry their own source ranges, such as those of confighcl validation
+				var configDiags hcl.Diagnostics
+				var configDiag *hcl.Diagnostic
+				switch {
+				case errors.As(err, &configDiags):
+					return append(diags, configDiags...)
+				case errors.As(err, &configDiag):
+					return diags.Append(configDiag)
+				}
+
+				diags = diags.Append(&hcl.Diagnostic{
 					Severity: hcl.DiagError,
-					Summary:  "Configuration file not found",
-					Detail:   fmt.Sprintf("The configuration file %s does not exist.", filename),
-				},
+					Summary:  "Failed to notify module config",
+					Detail:   fmt.Sprintf("Module %s returned an error on ConfigSet: %v.", cfg.name, err),
+				})
+				return diags
 			}
 		}
+	}
 
-		return hcl.Diagnostics{
-			{
-				Severity: hcl.DiagError,
-				Summary:  "Failed to read configuration",
-				Detail:   fmt.Sprintf("Can't read %s: %s.", filename, err),
-			},
+	return diags
+}
+
+// ReloadFiles will open and decode the provided files and directories into newly allocated configurations for the
+// Configurable modules and pass them to the modules implementing Reloader. Nothing is reloaded when decoding fails.
+func (c *Configuration) ReloadFiles(ctx context.Context, paths ...string) error {
+	body, diags := c.ParseFiles(paths...)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	configs, diags := c.decodeModules(ctx, body, true)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	var reloadErr *Error
+	for _, cfg := range configs {
+		if reloader, ok := cfg.module.(Reloader); ok {
+			if err := reloader.Reload(ctx, cfg.value); err != nil {
+				reloadErr = reloadErr.Append(fmt.Errorf("failed to reload module %q: %w", cfg.name, err))
+			}
 		}
 	}
 
-	return c.Decode(ctx, filename, src)
+	if reloadErr != nil {
+		return reloadErr.Err()
+	}
+
+	return nil
 }
 
-func (c Configuration) Decode(ctx context.Context, filename string, src []byte) hcl.Diagnostics {
+// ParseFiles parses the provided files and directories into a single body merged in order, directories include all
+// files with a supported extension sorted by name
+func (c *Configuration) ParseFiles(paths ...string) (hcl.Body, hcl.Diagnostics) {
+	filenames, diags := expandPaths(paths)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	files := make([]*hcl.File, 0, len(filenames))
+	for _, filename := range filenames {
+		src, fileDiags := readFile(filename)
+		diags = append(diags, fileDiags...)
+		if fileDiags.HasErrors() {
+			continue
+		}
+
+		file, fileDiags := c.Parse(filename, src)
+		diags = append(diags, fileDiags...)
+		if file != nil {
+			files = append(files, file)
+		}
+	}
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return hcl.MergeFiles(files), diags
+}
+
+// Parse the provided source into an hcl.File using the format of the filename extension
+func (c *Configuration) Parse(filename string, src []byte) (*hcl.File, hcl.Diagnostics) {
 	var file *hcl.File
 	var diags hcl.Diagnostics
 
@@ -60,33 +205,75 @@ func (c Configuration) Decode(ctx context.Context, filename string, src []byte)
 		file, diags = hclsyntax.ParseConfig(src, filename, hcl.Pos{Line: 1, Column: 1})
 	case ".json":
 		file, diags = json.Parse(src, filename)
+	case ".yaml", ".yml":
+		file, diags = yaml.Parse(src, filename)
+	case ".toml":
+		file, diags = toml.Parse(src, filename)
 	default:
 		diags = diags.Append(&hcl.Diagnostic{
 			Severity: hcl.DiagError,
 			Summary:  "Unsupported file format",
 			Detail:   fmt.Sprintf("Cannot read from %s: unrecognized file format suffix %q.", filename, suffix),
 		})
-		return diags
 	}
-	if diags.HasErrors() {
-		return diags
+
+	if file != nil {
+		if c.files == nil {
+			c.files = make(map[string]*hcl.File)
+		}
+		c.files[filename] = file
+
+		file = scopeFile(file, filename)
 	}
 
+	return file, diags
+}
+
+// moduleConfig is the decoded configuration of a module
+type moduleConfig struct {
+	name   string
+	module Module
+	value  interface{}
+}
+
+// decodeModules decodes the body into the configuration of every Configurable module of the application in the context,
+// when fresh is set the configurations are decoded into new values rather than the ones returned from Config
+func (c *Configuration) decodeModules(ctx context.Context, body hcl.Body, fresh bool) ([]moduleConfig, hcl.Diagnostics) {
 	evalContext := c.EvalContext(ctx)
+	app := FromContext(ctx)
+
+	remain, diags := c.decodeVariables(body, evalContext)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	keys := []keySuggestion{{name: variableBlockType}, {name: localsBlockType}}
+	var blocks map[string]*hcl.Block
+	if c.blocks && app != nil {
+		names := configurableNames(app)
+
+		var blockDiags hcl.Diagnostics
+		blocks, remain, blockDiags = moduleBlocks(remain, names)
+		if diags = append(diags, blockDiags...); diags.HasErrors() {
+			return nil, diags
+		}
+		keys = append(keys, headerKeys(names)...)
+	}
 
 	target := struct {
 		Configuration hcl.Body `config:",remain"`
 	}{}
 
-	diags = confighcl.DecodeBody(file.Body, evalContext, &target)
-	if diags.HasErrors() {
-		return diags
+	if diags = append(diags, confighcl.DecodeBody(remain, evalContext, &target)...); diags.HasErrors() {
+		return nil, diags
 	}
 
-	app := FromContext(ctx)
+	var configs []moduleConfig
+
 	if app != nil {
+		var modules []moduleConfig
 
-		// loop through the modules, see if configurable, then apply the configs
+		// loop through the modules, see if configurable, then collect the configs
 		app.Controller.Range(func(name string, m Module) bool {
 			cfgr, ok := m.(Configurable)
 			if !ok {
@@ -103,40 +290,382 @@ func (c Configuration) Decode(ctx context.Context, filename string, src []byte)
 				return false
 			}
 
-			target.Configuration, diags = confighcl.DecodeLeftoverBody(target.Configuration, evalContext, v)
-
-			if diags.HasErrors() {
-				return false
+			if isNil(v) {
+				return true
 			}
 
-			if notifier, ok := m.(ConfigurableNotify); ok {
-				if err := notifier.ConfigSet(v); err != nil {
-					diags = diags.Append(&hcl.Diagnostic{
-						Severity: hcl.DiagError,
-						Summary:  "Failed to notify module config",
-						Detail:   fmt.Sprintf("Module %s returned an error on ConfigSet: %v.", name, err),
-					})
-					return false
-				}
+			if fresh {
+				v = c.fresh(name, v)
+			} else {
+				c.snapshot(name, v)
 			}
 
+			modules = append(modules, moduleConfig{name: name, module: m, value: v})
+
 			return true
 		})
 
 		if diags.HasErrors() {
-			return diags
+			return nil, diags
+		}
+
+		// modules referring to the configuration of other modules are decoded after them
+		modules, referenced, orderDiags := moduleOrder(modules, func(name string) hcl.Body {
+			if c.blocks {
+				return moduleBody(blocks, name, body)
+			}
+			return target.Configuration
+		})
+		if diags = append(diags, orderDiags...); diags.HasErrors() {
+			return nil, diags
+		}
+
+		values := make(map[string]cty.Value, len(modules))
+		evalContext.Variables["module"] = cty.EmptyObjectVal
+
+		pure := pureContext(evalContext)
+
+		for _, cfg := range modules {
+			name, v := cfg.name, cfg.value
+
+			// the configuration other modules refer to must not change between evaluations
+			moduleContext := evalContext
+			if referenced[name] {
+				moduleContext = pure
+			}
+
+			var moduleDiags hcl.Diagnostics
+			if c.blocks {
+				var moduleRemain hcl.Body
+				moduleRemain, moduleDiags = confighcl.DecodeLeftoverBody(moduleBody(blocks, name, body), moduleContext, v)
+				if !moduleDiags.HasErrors() {
+					moduleDiags = append(moduleDiags, c.unknownKeys(moduleRemain, moduleKeys(name, v))...)
+				}
+			} else {
+				target.Configuration, moduleDiags = confighcl.DecodeLeftoverBody(target.Configuration, moduleContext, v)
+				keys = append(keys, moduleKeys(name, v)...)
+			}
+
+			if c.env && !moduleDiags.HasErrors() {
+				moduleDiags = append(moduleDiags, confighcl.DecodeEnv(confighcl.EnvName(c.envPrefix, name), moduleContext, v)...)
+			}
+
+			moduleDiags = append(moduleDiags, confighcl.DecodeFields(c.flags[name], moduleContext, v)...)
+
+			if diags = append(diags, moduleDiags...); diags.HasErrors() {
+				return nil, diags
+			}
+
+			// the decoded configuration is available to the modules decoded next
+			if referenced[name] {
+				values[name] = confighcl.EncodeValue(v)
+				evalContext.Variables["module"] = cty.ObjectVal(values)
+			}
+
+			configs = append(configs, cfg)
 		}
 	}
 
-	return confighcl.DecodeBody(target.Configuration, evalContext, new(struct{}))
+	return configs, append(diags, c.unknownKeys(target.Configuration, keys)...)
 }
 
-// EvalContext returns the hcl.EvalContext for loading hcl files
-func (Configuration) EvalContext(ctx context.Context) *hcl.EvalContext {
+// EnvVars lists the environment variables overriding the configuration of every Configurable module of the application
+// in the context, with the module name as the first element of each path. Nothing is listed unless overrides are enabled.
+func (c *Configuration) EnvVars(ctx context.Context) []confighcl.EnvVar {
+	app := FromContext(ctx)
+	if !c.env || app == nil {
+		return nil
+	}
+
+	var vars []confighcl.EnvVar
+	app.Controller.Range(func(name string, m Module) bool {
+		cfgr, ok := m.(Configurable)
+		if !ok {
+			return true
+		}
+
+		v, err := cfgr.Config()
+		if err != nil || isNil(v) {
+			return true
+		}
+
+		for _, ev := range confighcl.EnvVars(confighcl.EnvName(c.envPrefix, name), v) {
+			ev.Path = append([]string{name}, ev.Path...)
+			vars = append(vars, ev)
+		}
+
+		return true
+	})
+
+	return vars
+}
+
+// JSONSchema produces the JSON Schema of the configuration of every Configurable module of the application in the
+// context. The properties of every module are merged into the top level object, which allows no other properties than
+// the variable and locals blocks and the "//" comments of the JSON syntax. When module blocks are enabled, each module is
+// instead a subschema in $defs named by the module, referenced by the property of the block configuring it.
+func (c *Configuration) JSONSchema(ctx context.Context) *confighcl.JSONSchema {
+	schema := &confighcl.JSONSchema{
+		Schema:               confighcl.JSONSchemaDialect,
+		Type:                 "object",
+		AdditionalProperties: confighcl.FalseJSONSchema(),
+	}
+
+	app := FromContext(ctx)
+	if app == nil {
+		return schema
+	}
+
+	schema.Title = fmt.Sprintf("%s configuration", app.Name)
+	schema.Properties = map[string]*confighcl.JSONSchema{
+		jsonCommentProperty: {},
+		variableBlockType: {
+			Type: "object",
+			AdditionalProperties: &confighcl.JSONSchema{
+				Type: "object",
+				Properties: map[string]*confighcl.JSONSchema{
+					"default":     {},
+					"description": {Type: "string"},
+				},
+				AdditionalProperties: confighcl.FalseJSONSchema(),
+			},
+		},
+		localsBlockType: {Type: "object"},
+	}
+
+	var headers map[string]moduleHeader
+	if c.blocks {
+		headers = moduleHeaders(configurableNames(app))
+		schema.Defs = map[string]*confighcl.JSONSchema{}
+	}
+
+	app.Controller.Range(func(name string, m Module) bool {
+		cfgr, ok := m.(Configurable)
+		if !ok {
+			return true
+		}
+
+		v, err := cfgr.Config()
+		if err != nil || isNil(v) {
+			return true
+		}
+
+		moduleSchema := confighcl.ImpliedJSONSchema(v)
+
+		if !c.blocks {
+			// modules share the top level, so a property declared by several modules must be valid for each of them
+			for prop, propSchema := range moduleSchema.Properties {
+				if existing, found := schema.Properties[prop]; found {
+					propSchema = &confighcl.JSONSchema{AllOf: []*confighcl.JSONSchema{existing, propSchema}}
+				}
+				schema.Properties[prop] = propSchema
+			}
+			for _, prop := range moduleSchema.Required {
+				if i := sort.SearchStrings(schema.Required, prop); i == len(schema.Required) || schema.Required[i] != prop {
+					schema.Required = append(schema.Required, prop)
+					sort.Strings(schema.Required)
+				}
+			}
+
+			return true
+		}
+
+		moduleSchema.Title = fmt.Sprintf("%s module", name)
+		schema.Defs[name] = moduleSchema
+		ref := &confighcl.JSONSchema{Ref: "#/$defs/" + name}
+
+		blockProperty(schema, moduleBlockType).Properties[name] = ref
+		if header := headers[name]; len(header.labels) == 0 {
+			schema.Properties[header.blockType] = ref
+		} else if header.blockType != moduleBlockType {
+			blockProperty(schema, header.blockType).Properties[header.labels[0]] = ref
+		}
+
+		return true
+	})
+
+	return schema
+}
+
+// SampleConfig encodes the current configuration of every Configurable module of the application in the context as a
+// commented HCL configuration file, see confighcl.EncodeSampleIntoBody. The configuration values of modules that have
+// not been configured are their defaults. When module blocks are enabled, each module is written in its block.
+func (c *Configuration) SampleConfig(ctx context.Context) []byte {
+	f := hclwrite.NewEmptyFile()
+
+	app := FromContext(ctx)
+	if app == nil {
+		return f.Bytes()
+	}
+
+	var headers map[string]moduleHeader
+	if c.blocks {
+		headers = moduleHeaders(configurableNames(app))
+	}
+
+	body := f.Body()
+	body.AppendUnstructuredTokens(confighcl.Comment(fmt.Sprintf("Sample configuration of %s %s", app.Name, app.Version)))
+
+	app.Controller.Range(func(name string, m Module) bool {
+		cfgr, ok := m.(Configurable)
+		if !ok {
+			return true
+		}
+
+		v, err := cfgr.Config()
+		if err != nil || isNil(v) {
+			return true
+		}
+
+		module := hclwrite.NewEmptyFile()
+		confighcl.EncodeSampleIntoBody(v, module.Body())
+
+		body.AppendNewline()
+		body.AppendUnstructuredTokens(confighcl.Comment(fmt.Sprintf("Configuration of the %s module", name)))
+		body.AppendNewline()
+
+		tokens := module.Body().BuildTokens(nil)
+		for len(tokens) > 0 && tokens[0].Type == hclsyntax.TokenNewline {
+			tokens = tokens[1:]
+		}
+
+		if c.blocks {
+			header := headers[name]
+			body.AppendNewBlock(header.blockType, header.labels).Body().AppendUnstructuredTokens(tokens)
+			return true
+		}
+		body.AppendUnstructuredTokens(tokens)
+
+		return true
+	})
+
+	return hclwrite.Format(f.Bytes())
+}
+
+// Reference produces Markdown reference documentation of the configuration of every Configurable module of the
+// application in the context, see confighcl.MarkdownReference.
+func (c *Configuration) Reference(ctx context.Context) []byte {
+	var buf bytes.Buffer
+
+	app := FromContext(ctx)
+	if app == nil {
+		return buf.Bytes()
+	}
+
+	fmt.Fprintf(&buf, "# %s configuration\n\n", app.Name)
+	var headers map[string]moduleHeader
+	if c.blocks {
+		headers = moduleHeaders(configurableNames(app))
+		fmt.Fprintf(&buf, "The attributes and blocks of every module are set in the block configuring the module in the configuration files of %s %s.\n", app.Name, app.Version)
+	} else {
+		fmt.Fprintf(&buf, "The attributes and blocks of every module are set at the top level of the configuration files of %s %s.\n", app.Name, app.Version)
+	}
+
+	app.Controller.Range(func(name string, m Module) bool {
+		cfgr, ok := m.(Configurable)
+		if !ok {
+			return true
+		}
+
+		v, err := cfgr.Config()
+		if err != nil || isNil(v) {
+			return true
+		}
+
+		fmt.Fprintf(&buf, "\n## `%s` module\n", name)
+		if c.blocks {
+			if header := headers[name]; header.blockType == moduleBlockType {
+				fmt.Fprintf(&buf, "\nConfigured by the `%s` block.\n", header)
+			} else {
+				fmt.Fprintf(&buf, "\nConfigured by the `%s` block, or the `%s %q` block.\n", header, moduleBlockType, name)
+			}
+		}
+		if reference := confighcl.MarkdownReference(v, 3); len(reference) > 0 {
+			buf.WriteString("\n")
+			buf.Write(reference)
+		}
+
+		return true
+	})
+
+	return buf.Bytes()
+}
+
+// jsonCommentProperty is the property of the JSON syntax whose value is a comment, ignored in every object
+const jsonCommentProperty = "//"
+
+// blockProperty returns the property of the labeled blocks of the type in the schema, an object of the blocks by label
+func blockProperty(schema *confighcl.JSONSchema, blockType string) *confighcl.JSONSchema {
+	property, ok := schema.Properties[blockType]
+	if !ok {
+		property = &confighcl.JSONSchema{
+			Type:                 "object",
+			Properties:           map[string]*confighcl.JSONSchema{},
+			AdditionalProperties: confighcl.FalseJSONSchema(),
+		}
+		schema.Properties[blockType] = property
+	}
+
+	return property
+}
+
+// baseDir returns the directory of the first configuration path of the application in the context, which is the
+// path itself when it is a directory, or the working directory when there is none
+func baseDir(ctx context.Context) string {
+	app := FromContext(ctx)
+	if app == nil || len(app.configFiles) == 0 {
+		return "."
+	}
+
+	if info, err := os.Stat(app.configFiles[0]); err == nil && info.IsDir() {
+		return app.configFiles[0]
+	}
+
+	return filepath.Dir(app.configFiles[0])
+}
+
+// overrides returns whether the configuration is overridden by environment variables or by at least one flag
+func (c *Configuration) overrides() bool {
+	return c.env || len(c.flags) > 0
+}
+
+// snapshot keeps a copy of the module configuration the first time it is decoded
+func (c *Configuration) snapshot(name string, v interface{}) {
+	if c.defaults == nil {
+		c.defaults = make(map[string]reflect.Value)
+	}
+
+	if _, found := c.defaults[name]; found {
+		return
+	}
+
+	c.defaults[name] = deepCopy(reflect.ValueOf(v).Elem())
+}
+
+// fresh allocates a configuration of the same type as v with the values it had before it was first decoded
+func (c *Configuration) fresh(name string, v interface{}) interface{} {
+	rv := reflect.New(reflect.TypeOf(v).Elem())
+	if d, found := c.defaults[name]; found {
+		rv.Elem().Set(deepCopy(d))
+	}
+
+	return rv.Interface()
+}
+
+// EvalContext returns the hcl.EvalContext for loading hcl files. The file functions such as file and templatefile of
+// the files returned by Parse resolve relative paths from the directory of the file, those of the context from the
+// directory of the first configuration path of the application in the context.
+func (c *Configuration) EvalContext(ctx context.Context) *hcl.EvalContext {
 	var result hcl.EvalContext
 
 	// functions
 	result.Functions = funcs.Stdlib()
+	for name, f := range funcs.Filesystem(baseDir(ctx)) {
+		result.Functions[name] = f
+	}
+	for name, provider := range c.secrets {
+		result.Functions[name] = secretFunc(ctx, provider)
+	}
 
 	// variables
 	allMap := map[string]interface{}{}
@@ -268,3 +797,124 @@ func ctyify(src map[string]interface{}) (map[string]cty.Value, error) {
 
 	return dst, nil
 }
+
+// expandPaths replaces the directories in paths with the configuration files they contain sorted by name
+func expandPaths(paths []string) ([]string, hcl.Diagnostics) {
+	var filenames []string
+	var diags hcl.Diagnostics
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			// readFile will report the details of any error
+			filenames = append(filenames, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Failed to read configuration directory",
+				Detail:   fmt.Sprintf("Can't read %s: %s.", path, err),
+			})
+			continue
+		}
+
+		// entries are sorted by filename
+		for _, entry := range entries {
+			if entry.IsDir() || !isConfigFile(entry.Name()) {
+				continue
+			}
+
+			filenames = append(filenames, filepath.Join(path, entry.Name()))
+		}
+	}
+
+	return filenames, diags
+}
+
+// isConfigFile returns true when the file has an extension Parse supports
+func isConfigFile(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".hcl", ".json", ".yaml", ".yml", ".toml":
+		return true
+	}
+
+	return false
+}
+
+// readFile reads the configuration file, returning diagnostics when it cannot be read
+func readFile(filename string) ([]byte, hcl.Diagnostics) {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, hcl.Diagnostics{
+				{
+					Severity: hcl.DiagError,
+					Summary:  "Configuration file not found",
+					Detail:   fmt.Sprintf("The configuration file %s does not exist.", filename),
+				},
+			}
+		}
+
+		return nil, hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  "Failed to read configuration",
+				Detail:   fmt.Sprintf("Can't read %s: %s.", filename, err),
+			},
+		}
+	}
+
+	return src, nil
+}
+
+// deepCopy copies the value including the contents of any pointers, slices and maps so modifying the copy can never
+// modify the original
+func deepCopy(v reflect.Value) reflect.Value {
+	result := reflect.New(v.Type()).Elem()
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return result
+		}
+		ptr := reflect.New(v.Type().Elem())
+		ptr.Elem().Set(deepCopy(v.Elem()))
+		result.Set(ptr)
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return result
+		}
+		result.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			result.Index(i).Set(deepCopy(v.Index(i)))
+		}
+
+	case reflect.Map:
+		if v.IsNil() {
+			return result
+		}
+		result.Set(reflect.MakeMapWithSize(v.Type(), v.Len()))
+		iter := v.MapRange()
+		for iter.Next() {
+			result.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+
+	case reflect.Struct:
+		// copy everything, then replace the fields we're able to with copies
+		result.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if result.Field(i).CanSet() {
+				result.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+
+	default:
+		result.Set(v)
+	}
+
+	return result
+}
@@ -2,13 +2,27 @@ package application
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/portcullis/application/confighcl"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func TestHCL(t *testing.T) {
@@ -68,3 +82,1615 @@ func TestHCL(t *testing.T) {
 		})
 	}
 }
+
+type reloadConfig struct {
+	Listen string   `config:"listen,optional"`
+	Hosts  []string `config:"hosts,optional"`
+}
+
+type reloadModule struct {
+	config   reloadConfig
+	reloaded []*reloadConfig
+}
+
+func (m *reloadModule) Start(context.Context) error { return nil }
+func (m *reloadModule) Stop(context.Context) error  { return nil }
+
+func (m *reloadModule) Config() (interface{}, error) {
+	return &m.config, nil
+}
+
+func (m *reloadModule) Reload(ctx context.Context, config interface{}) error {
+	m.reloaded = append(m.reloaded, config.(*reloadConfig))
+	return nil
+}
+
+func TestReload(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.hcl")
+	if err := os.WriteFile(filename, []byte(`listen = ":8080"`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &reloadModule{config: reloadConfig{Listen: ":80", Hosts: []string{"localhost"}}}
+	app := New("test", "1.0.0", WithConfigFile(filename), WithModule("http", m))
+	if err := app.Validate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if m.config.Listen != ":8080" {
+		t.Fatalf("configuration not decoded: %+v", m.config)
+	}
+
+	// removing the attribute reloads the value from before the configuration was decoded
+	if err := os.WriteFile(filename, []byte(`hosts = ["example.com"]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.Reload(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(m.reloaded) != 1 {
+		t.Fatalf("module was not reloaded")
+	}
+
+	if expected := (reloadConfig{Listen: ":80", Hosts: []string{"example.com"}}); !reflect.DeepEqual(*m.reloaded[0], expected) {
+		t.Errorf("unexpected reloaded configuration: expected %+v; got %+v", expected, *m.reloaded[0])
+	}
+
+	if m.config.Listen != ":8080" || m.config.Hosts[0] != "localhost" {
+		t.Errorf("current configuration was modified: %+v", m.config)
+	}
+
+	// failing to decode keeps the current configuration
+	if err := os.WriteFile(filename, []byte(`listen = `), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.Reload(context.Background()); err == nil {
+		t.Fatalf("expected reload error")
+	}
+
+	if len(m.reloaded) != 1 {
+		t.Errorf("module was reloaded with an invalid configuration")
+	}
+
+	t.Run("decode file", func(t *testing.T) {
+		// the values from before DecodeFile are kept for reloading, like for DecodeFiles
+		if err := os.WriteFile(filename, []byte(`listen = ":8080"`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		m := &reloadModule{config: reloadConfig{Listen: ":80"}}
+		ctx := context.WithValue(context.Background(), applicationContextKey, New("test", "1.0.0", WithModule("http", m)))
+
+		cfg := &Configuration{}
+		if diags := cfg.DecodeFile(ctx, filename); diags.HasErrors() {
+			t.Fatal(diags.Error())
+		}
+
+		if err := os.WriteFile(filename, []byte(`hosts = ["example.com"]`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := cfg.ReloadFiles(ctx, filename); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(m.reloaded) != 1 {
+			t.Fatal("module was not reloaded")
+		}
+		if m.reloaded[0].Listen != ":80" {
+			t.Errorf("unexpected reloaded configuration: %+v", *m.reloaded[0])
+		}
+	})
+}
+
+func TestDecodeFiles(t *testing.T) {
+	dir := t.TempDir()
+	fragments := filepath.Join(dir, "conf.d")
+	if err := os.Mkdir(fragments, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"base.hcl":                 `listen = ":8080"`,
+		"conf.d/20-hosts.json":     `{"hosts": ["example.com"]}`,
+		"conf.d/10-duplicate.hcl":  `listen = ":9090"`,
+		"conf.d/ignored.txt":       `listen = ":1"`,
+		"conf.d/30-unsupported.md": `# ignored`,
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := &reloadModule{}
+	app := New("test", "1.0.0", WithModule("http", m))
+	ctx := app.initialize(context.Background())
+
+	cfg := &Configuration{}
+	if diags := cfg.DecodeFiles(ctx, filepath.Join(dir, "base.hcl"), filepath.Join(fragments, "20-hosts.json")); diags.HasErrors() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	if expected := (reloadConfig{Listen: ":8080", Hosts: []string{"example.com"}}); !reflect.DeepEqual(m.config, expected) {
+		t.Errorf("unexpected configuration: expected %+v; got %+v", expected, m.config)
+	}
+
+	// the directory includes an attribute that is already defined in base.hcl
+	diags := cfg.DecodeFiles(ctx, filepath.Join(dir, "base.hcl"), fragments)
+	if !diags.HasErrors() {
+		t.Fatal("expected duplicate argument error")
+	}
+
+	if diags[0].Subject == nil || diags[0].Subject.Filename != filepath.Join(fragments, "10-duplicate.hcl") || diags[0].Subject.Start.Line != 1 {
+		t.Errorf("unexpected diagnostic subject: %v", diags[0].Subject)
+	}
+
+	if !strings.Contains(diags[0].Detail, "base.hcl:1") {
+		t.Errorf("diagnostic does not reference the original definition: %s", diags[0].Detail)
+	}
+}
+
+type formatConfig struct {
+	Name    string            `config:"name"`
+	Port    int               `config:"port,optional"`
+	Ratio   float64           `config:"ratio,optional"`
+	Enabled bool              `config:"enabled,optional"`
+	Tags    []string          `config:"tags,optional"`
+	Labels  map[string]string `config:"labels,optional"`
+	Routes  []formatRoute     `config:"route,block"`
+}
+
+type formatRoute struct {
+	Path    string `config:"path,label"`
+	Backend string `config:"backend"`
+}
+
+func TestFormats(t *testing.T) {
+	os.Setenv("TEST", "set-from-env")
+	defer os.Unsetenv("TEST")
+
+	expected := formatConfig{
+		Name:    "set-from-env",
+		Port:    8080,
+		Ratio:   0.25,
+		Enabled: true,
+		Tags:    []string{"a", "b"},
+		Labels:  map[string]string{"team": "platform"},
+		Routes: []formatRoute{
+			{Path: "/api", Backend: "api:80"},
+			{Path: "/web", Backend: "web:80"},
+		},
+	}
+
+	tests := []struct {
+		Name  string
+		Input string
+	}{
+		{
+			Name: "test.hcl",
+			Input: `
+name    = env("TEST", "")
+port    = 8080
+ratio   = 0.25
+enabled = true
+tags    = ["a", "b"]
+labels  = { team = "platform" }
+
+route "/api" {
+  backend = "api:80"
+}
+
+route "/web" {
+  backend = "web:80"
+}
+`,
+		},
+		{
+			Name: "test.yaml",
+			Input: `
+defaults: &backend
+  backend: web:80
+name: ${env("TEST", "")}
+port: 8080
+ratio: 0.25
+enabled: true
+tags: [a, b]
+labels:
+  team: platform
+route:
+  /api:
+    <<: *backend
+    backend: api:80
+  /web: *backend
+`,
+		},
+		{
+			Name: "test.yml",
+			Input: `
+name: '${env("TEST", "")}'
+port: 0x1F90
+ratio: 25e-2
+enabled: True
+tags:
+  - a
+  - b
+labels: {team: platform}
+route:
+  - /api:
+      backend: api:80
+  - /web:
+      backend: web:80
+`,
+		},
+		{
+			Name: "test.toml",
+			Input: `
+name = '${env("TEST", "")}'
+port = 8_080
+ratio = 0.25
+enabled = true
+tags = ["a", "b"]
+labels = { team = "platform" }
+
+[route."/api"]
+backend = "api:80"
+
+[[route."/web"]]
+backend = "web:80"
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			cfg := &Configuration{}
+			file, diags := cfg.Parse(test.Name, []byte(test.Input))
+			if diags.HasErrors() {
+				t.Fatal(diags.Error())
+			}
+
+			var value formatConfig
+			diags = confighcl.DecodeBody(file.Body, cfg.EvalContext(context.Background()), &value)
+
+			// the yaml anchor is not part of the configuration
+			if strings.HasSuffix(test.Name, ".yaml") {
+				if len(diags) != 1 || diags[0].Subject.Start.Line != 2 || !strings.Contains(diags[0].Detail, `"defaults"`) {
+					t.Fatalf("expected unsupported argument error for defaults; got %v", diags)
+				}
+				diags = nil
+			}
+
+			if diags.HasErrors() {
+				t.Fatal(diags.Error())
+			}
+
+			if !reflect.DeepEqual(value, expected) {
+				t.Errorf("unexpected configuration: expected %+v; got %+v", expected, value)
+			}
+		})
+	}
+}
+
+func TestFormatDiagnostics(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Input  string
+		Line   int
+		Column int
+		Detail string
+	}{
+		{
+			Name:   "type.yaml",
+			Input:  "name: test\nport: \"eighty\"\n",
+			Line:   2,
+			Column: 7,
+			Detail: "a number is required",
+		},
+		{
+			Name:   "unknown.yaml",
+			Input:  "name: test\nprot: 80\n",
+			Line:   2,
+			Column: 1,
+			Detail: `Did you mean "port"?`,
+		},
+		{
+			Name:   "syntax.yaml",
+			Input:  "name: test\nport 80\nenabled: true\n",
+			Line:   2,
+			Column: 1,
+			Detail: "could not find expected ':'",
+		},
+		{
+			Name:   "type.toml",
+			Input:  "name = \"test\"\n\n  port = \"eighty\"\n",
+			Line:   3,
+			Column: 10,
+			Detail: "a number is required",
+		},
+		{
+			Name:   "unknown.toml",
+			Input:  "name = \"test\"\nprot = 80\n",
+			Line:   2,
+			Column: 1,
+			Detail: `Did you mean "port"?`,
+		},
+		{
+			Name:   "duplicate.toml",
+			Input:  "name = \"test\"\nname = \"again\"\n",
+			Line:   2,
+			Column: 1,
+			Detail: "already defined at duplicate.toml:1",
+		},
+		{
+			Name:   "syntax.toml",
+			Input:  "name = \"test\"\nport = 80 80\n",
+			Line:   2,
+			Column: 11,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			cfg := &Configuration{}
+			file, diags := cfg.Parse(test.Name, []byte(test.Input))
+			if !diags.HasErrors() {
+				var value formatConfig
+				diags = confighcl.DecodeBody(file.Body, cfg.EvalContext(context.Background()), &value)
+			}
+
+			if !diags.HasErrors() {
+				t.Fatal("expected error")
+			}
+
+			subject := diags[0].Subject
+			if subject == nil || subject.Filename != test.Name || subject.Start.Line != test.Line || subject.Start.Column != test.Column {
+				t.Errorf("unexpected subject: expected %s:%d,%d; got %v", test.Name, test.Line, test.Column, subject)
+			}
+
+			if !strings.Contains(diags[0].Detail, test.Detail) {
+				t.Errorf("unexpected detail: expected %q; got %q", test.Detail, diags[0].Detail)
+			}
+		})
+	}
+}
+
+type envConfig struct {
+	ListenAddr string        `config:"listen_addr,optional"`
+	Timeout    time.Duration `config:"timeout,optional"`
+	Hosts      []string      `config:"hosts,optional"`
+	TLS        *envTLSConfig `config:"tls,block"`
+}
+
+type envTLSConfig struct {
+	Cert    string `config:"cert"`
+	Enabled bool   `config:"enabled,optional"`
+}
+
+type envModule struct {
+	config envConfig
+	set    *envConfig
+}
+
+func (m *envModule) Start(context.Context) error { return nil }
+func (m *envModule) Stop(context.Context) error  { return nil }
+
+func (m *envModule) Config() (interface{}, error) {
+	return &m.config, nil
+}
+
+func (m *envModule) ConfigSet(config interface{}) error {
+	c := *config.(*envConfig)
+	m.set = &c
+	return nil
+}
+
+func TestEnvOverrides(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.hcl")
+	if err := os.WriteFile(filename, []byte(`
+listen_addr = ":8080"
+timeout     = "1s"
+hosts       = ["example.com"]
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("APP_HTTP_LISTEN_ADDR", ":9090")
+	t.Setenv("APP_HTTP_TIMEOUT", "5s")
+	t.Setenv("APP_HTTP_HOSTS", `["a.example.com", "b.example.com"]`)
+	t.Setenv("APP_HTTP_TLS_ENABLED", "true")
+	t.Setenv("APP_HTTP_TLS_CERT", "cert.pem")
+
+	m := &envModule{}
+	app := New("test", "1.0.0", WithModule("http", m), WithEnvOverrides("APP"), WithConfigFile(filename))
+	if err := app.Validate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := envConfig{
+		ListenAddr: ":9090",
+		Timeout:    5 * time.Second,
+		Hosts:      []string{"a.example.com", "b.example.com"},
+		TLS:        &envTLSConfig{Cert: "cert.pem", Enabled: true},
+	}
+	if !reflect.DeepEqual(m.config, expected) {
+		t.Errorf("unexpected configuration: expected %+v; got %+v", expected, m.config)
+	}
+
+	if m.set == nil || !reflect.DeepEqual(*m.set, expected) {
+		t.Errorf("ConfigSet was not called with the overridden configuration: %+v", m.set)
+	}
+
+	var names []string
+	for _, ev := range app.EnvVars() {
+		names = append(names, ev.Name)
+	}
+
+	expectedNames := []string{"APP_HTTP_HOSTS", "APP_HTTP_LISTEN_ADDR", "APP_HTTP_TIMEOUT", "APP_HTTP_TLS_CERT", "APP_HTTP_TLS_ENABLED"}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("unexpected variables: expected %v; got %v", expectedNames, names)
+	}
+
+	t.Run("without files", func(t *testing.T) {
+		m := &envModule{}
+		app := New("test", "1.0.0", WithModule("http", m), WithEnvOverrides("APP"))
+		if err := app.Validate(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if m.config.ListenAddr != ":9090" {
+			t.Errorf("unexpected listen address: %q", m.config.ListenAddr)
+		}
+	})
+
+	t.Run("required", func(t *testing.T) {
+		// the absent tls block is decoded from the variables set within it, so its cert is still required
+		t.Setenv("APP_HTTP_TLS_CERT", "")
+		os.Unsetenv("APP_HTTP_TLS_CERT")
+
+		app := New("test", "1.0.0", WithModule("http", &envModule{}), WithEnvOverrides("APP"))
+		err := app.Validate(context.Background())
+		if err == nil || !strings.Contains(err.Error(), `APP_HTTP_TLS_ENABLED:1,1-1: Missing required argument; The argument "cert" is required`) {
+			t.Errorf("expected error of the missing cert; got %v", err)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Setenv("APP_HTTP_TIMEOUT", "soon")
+
+		app := New("test", "1.0.0", WithModule("http", &envModule{}), WithEnvOverrides("APP"))
+		err := app.Validate(context.Background())
+		if err == nil || !strings.Contains(err.Error(), "APP_HTTP_TIMEOUT") {
+			t.Errorf("expected error referencing the variable; got %v", err)
+		}
+	})
+}
+
+type defaultsConfig struct {
+	Listen  string            `config:"listen,default=:8080"`
+	Host    string            `config:"host,optional,default=localhost"`
+	Timeout time.Duration     `config:"timeout,default=5s"`
+	Retries int               `config:"retries,default=3"`
+	Hosts   []string          `config:"hosts,default=[\"a\", \"b\"]"`
+	Labels  map[string]string `config:"labels,default={ team = \"platform\" }"`
+	TLS     *defaultsTLS      `config:"tls,block,default"`
+}
+
+type defaultsTLS struct {
+	Enabled bool   `config:"enabled,default=true"`
+	Cert    string `config:"cert,default=cert.pem"`
+}
+
+func TestDefaults(t *testing.T) {
+	cfg := &Configuration{}
+
+	expected := defaultsConfig{
+		Listen:  ":8080",
+		Host:    "localhost",
+		Timeout: 5 * time.Second,
+		Retries: 3,
+		Hosts:   []string{"a", "b"},
+		Labels:  map[string]string{"team": "platform"},
+		TLS:     &defaultsTLS{Enabled: true, Cert: "cert.pem"},
+	}
+
+	file, diags := cfg.Parse("test.hcl", nil)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	var value defaultsConfig
+	if diags := confighcl.DecodeBody(file.Body, cfg.EvalContext(context.Background()), &value); diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	if !reflect.DeepEqual(value, expected) {
+		t.Errorf("unexpected defaults: expected %+v; got %+v", expected, value)
+	}
+
+	file, diags = cfg.Parse("test.hcl", []byte(`
+timeout = "1m"
+retries = 0
+
+tls {
+  enabled = false
+}
+`))
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	value = defaultsConfig{}
+	if diags := confighcl.DecodeBody(file.Body, cfg.EvalContext(context.Background()), &value); diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	expected.Timeout = time.Minute
+	expected.Retries = 0
+	expected.TLS.Enabled = false
+	if !reflect.DeepEqual(value, expected) {
+		t.Errorf("unexpected configuration: expected %+v; got %+v", expected, value)
+	}
+
+	// explicit zero values are encoded as they are, so they survive decoding
+	f := hclwrite.NewEmptyFile()
+	confighcl.EncodeIntoBody(&value, f.Body())
+
+	file, diags = cfg.Parse("encoded.hcl", f.Bytes())
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	decoded := defaultsConfig{}
+	if diags := confighcl.DecodeBody(file.Body, cfg.EvalContext(context.Background()), &decoded); diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	if !reflect.DeepEqual(decoded, value) {
+		t.Errorf("unexpected decoded configuration: expected %+v; got %+v", value, decoded)
+	}
+
+	// blocks allocated for a field apply their defaults
+	value = defaultsConfig{}
+	for _, field := range confighcl.Fields(&value) {
+		if strings.Join(field.Path, ".") == "tls.cert" {
+			if diags := confighcl.DecodeField(field, "-tls.cert", "other.pem", cfg.EvalContext(context.Background()), &value); diags.HasErrors() {
+				t.Fatal(diags.Error())
+			}
+		}
+	}
+	if expected := (&defaultsTLS{Enabled: true, Cert: "other.pem"}); !reflect.DeepEqual(value.TLS, expected) {
+		t.Errorf("unexpected block of the field: expected %+v; got %+v", expected, value.TLS)
+	}
+
+	// samples show the defaults of the zero values
+	f = hclwrite.NewEmptyFile()
+	confighcl.EncodeSampleIntoBody(&defaultsConfig{Retries: 5}, f.Body())
+
+	encoded := string(f.Bytes())
+	for _, expected := range []string{
+		`listen = ":8080"`,
+		`timeout = "5s"`,
+		`retries = 5`,
+		`hosts = ["a", "b"]`,
+		`labels = { team = "platform" }`,
+		"  enabled = true\n",
+		"  cert = \"cert.pem\"\n",
+	} {
+		if !strings.Contains(encoded, expected) {
+			t.Errorf("encoded configuration does not contain %q:\n%s", expected, encoded)
+		}
+	}
+}
+
+type validateConfig struct {
+	Name     string        `config:"name,optional" validate:"nonempty,max=8,regex=^[a-z][a-z0-9,-]*$"`
+	Port     int           `config:"port,optional" validate:"port"`
+	Listen   string        `config:"listen,optional" validate:"port"`
+	Mode     string        `config:"mode,optional" validate:"oneof=plain tls"`
+	Cert     string        `config:"cert,optional" validate:"required_if=mode tls"`
+	Endpoint string        `config:"endpoint,optional" validate:"url"`
+	Hosts    []string      `config:"hosts,optional" validate:"min=1"`
+	Timeout  time.Duration `config:"timeout,optional,default=5s" validate:"min=1s,max=1m"`
+}
+
+type validateModule struct {
+	config validateConfig
+}
+
+func (m *validateModule) Start(context.Context) error { return nil }
+func (m *validateModule) Stop(context.Context) error  { return nil }
+
+func (m *validateModule) Config() (interface{}, error) {
+	return &m.config, nil
+}
+
+func (m *validateModule) ConfigSet(config interface{}) error {
+	if config.(*validateConfig).Name == "reserved" {
+		return hcl.Diagnostics{{Severity: hcl.DiagError, Summary: "Reserved name", Subject: &hcl.Range{Filename: "module.hcl"}}}
+	}
+
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	valid := `
+name     = "api,v1"
+port     = 8080
+listen   = "localhost:8080"
+mode     = "tls"
+cert     = "cert.pem"
+endpoint = "https://example.com/path"
+hosts    = ["a"]
+`
+
+	tests := []struct {
+		Name    string
+		Input   string
+		Line    int
+		Summary string
+		Detail  string
+	}{
+		{Name: "valid", Input: valid},
+		{Name: "nonempty", Input: `name = ""`, Line: 1, Detail: "The value of name must not be empty."},
+		{Name: "max length", Input: `name = "abcdefghi"`, Line: 1, Detail: "The value of name must have a length of at most 8."},
+		{Name: "regex", Input: `name = "1abc"`, Line: 1, Detail: "The value of name must match the regular expression ^[a-z][a-z0-9,-]*$."},
+		{Name: "port", Input: "\nport = 70000", Line: 2, Detail: "The value of port must be a port between 1 and 65535."},
+		{Name: "host port", Input: `listen = "localhost:http"`, Line: 1, Detail: "The value of listen must be a port between 1 and 65535."},
+		{Name: "oneof", Input: `mode = "mtls"`, Line: 1, Detail: "The value of mode must be one of plain, tls."},
+		{Name: "required if", Input: "mode = \"tls\"\n", Line: 1, Summary: "Missing required argument", Detail: `The argument "cert" is required when mode is tls.`},
+		{Name: "url", Input: `endpoint = "/path"`, Line: 1, Detail: "The value of endpoint must be an absolute URL."},
+		{Name: "min length", Input: `hosts = []`, Line: 1, Detail: "The value of hosts must have a length of at least 1."},
+		{Name: "duration", Input: `timeout = "2m"`, Line: 1, Detail: "The value of timeout must be at most 1m."},
+		{Name: "config set", Input: `name = "reserved"`, Summary: "Reserved name"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			app := New("test", "1.0.0", WithModule("test", &validateModule{}))
+			ctx := app.initialize(context.Background())
+
+			cfg := &Configuration{}
+			diags := cfg.Decode(ctx, "test.hcl", []byte(test.Input))
+			if test.Detail == "" && test.Summary == "" {
+				if diags.HasErrors() {
+					t.Fatal(diags.Error())
+				}
+				return
+			}
+
+			if len(diags) != 1 {
+				t.Fatalf("expected a single diagnostic; got %v", diags)
+			}
+
+			if test.Summary == "" {
+				test.Summary = "Invalid value"
+			}
+			if diags[0].Summary != test.Summary || diags[0].Detail != test.Detail {
+				t.Errorf("unexpected diagnostic: expected %s; %s; got %s; %s", test.Summary, test.Detail, diags[0].Summary, diags[0].Detail)
+			}
+
+			if test.Line > 0 && (diags[0].Subject == nil || diags[0].Subject.Filename != "test.hcl" || diags[0].Subject.Start.Line != test.Line) {
+				t.Errorf("unexpected subject: expected test.hcl:%d; got %v", test.Line, diags[0].Subject)
+			}
+		})
+	}
+
+	t.Run("flags", func(t *testing.T) {
+		app := New("test", "1.0.0", WithModule("test", &validateModule{}), WithFlags("-test.port", "0"), WithOutput(&strings.Builder{}))
+		if err := app.Validate(context.Background()); err == nil || !strings.Contains(err.Error(), "-test.port") {
+			t.Errorf("expected validation error for the flag; got %v", err)
+		}
+	})
+
+	t.Run("required if absent pointer", func(t *testing.T) {
+		var config struct {
+			Mode *string `config:"mode,optional"`
+			Cert string  `config:"cert,optional" validate:"required_if=mode tls"`
+		}
+
+		file, diags := hclsyntax.ParseConfig(nil, "test.hcl", hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatal(diags.Error())
+		}
+
+		if diags := confighcl.DecodeBody(file.Body, nil, &config); diags.HasErrors() {
+			t.Errorf("unexpected error: %s", diags.Error())
+		}
+	})
+}
+
+func TestJSONSchema(t *testing.T) {
+	app := New("test", "1.0.0", WithModule("http", &validateModule{}), WithModule("server", &envModule{}))
+
+	b, err := app.JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Fatalf("invalid schema: %v\n%s", err, b)
+	}
+
+	if schema["$schema"] != confighcl.JSONSchemaDialect || schema["title"] != "test configuration" {
+		t.Errorf("unexpected schema header:\n%s", b)
+	}
+
+	if _, found := schema["$defs"]; found || schema["additionalProperties"] != false {
+		t.Errorf("module properties are not merged into the top level:\n%s", b)
+	}
+
+	properties := schemaPath(t, schema, "properties")
+	for name, expected := range map[string]interface{}{
+		"//":       map[string]interface{}{},
+		"port":     map[string]interface{}{"type": "integer", "minimum": 1.0, "maximum": 65535.0},
+		"mode":     map[string]interface{}{"type": "string", "enum": []interface{}{"plain", "tls"}},
+		"endpoint": map[string]interface{}{"type": "string", "format": "uri"},
+		"hosts": map[string]interface{}{"allOf": []interface{}{
+			map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "minItems": 1.0},
+			map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		}},
+	} {
+		if !reflect.DeepEqual(properties[name], expected) {
+			t.Errorf("unexpected schema of %s: expected %v; got %v", name, expected, properties[name])
+		}
+	}
+
+	if _, required := schema["required"]; required {
+		t.Errorf("optional properties are required: %v", schema["required"])
+	}
+
+	tls := schemaPath(t, properties, "tls")
+	if !reflect.DeepEqual(tls["required"], []interface{}{"cert"}) {
+		t.Errorf("unexpected required properties of tls: %v", tls["required"])
+	}
+	if tls["additionalProperties"] != false {
+		t.Errorf("nested block allows additional properties: %v", tls)
+	}
+
+	b, err = json.Marshal(confighcl.ImpliedJSONSchema(&defaultsConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, required := schema["required"]; required {
+		t.Errorf("defaulted properties are required: %v", schema["required"])
+	}
+
+	defaults := schemaPath(t, schema, "properties")
+	for name, expected := range map[string]interface{}{
+		"listen":  ":8080",
+		"timeout": "5s",
+		"retries": 3.0,
+		"hosts":   []interface{}{"a", "b"},
+		"labels":  map[string]interface{}{"team": "platform"},
+	} {
+		if actual := schemaPath(t, defaults, name)["default"]; !reflect.DeepEqual(actual, expected) {
+			t.Errorf("unexpected default of %s: expected %v; got %v", name, expected, actual)
+		}
+	}
+
+	b, err = json.Marshal(confighcl.ImpliedJSONSchema(&formatConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	route := schemaPath(t, schema, "properties", "route", "additionalProperties")
+	anyOf, _ := route["anyOf"].([]interface{})
+	if len(anyOf) != 2 {
+		t.Fatalf("route blocks are not an object or an array: %v", route)
+	}
+	if backend := schemaPath(t, anyOf[0].(map[string]interface{}), "properties", "backend"); backend["type"] != "string" {
+		t.Errorf("unexpected schema of route backend: %v", backend)
+	}
+}
+
+// schemaPath returns the object at the path of keys in the decoded schema
+func schemaPath(t *testing.T, schema map[string]interface{}, path ...string) map[string]interface{} {
+	t.Helper()
+
+	for _, key := range path {
+		next, ok := schema[key].(map[string]interface{})
+		if !ok {
+			t.Fatalf("schema has no object %s in %v", key, schema)
+		}
+		schema = next
+	}
+
+	return schema
+}
+
+func TestSampleConfig(t *testing.T) {
+	defaults := envConfig{ListenAddr: ":8080", Timeout: 5 * time.Second}
+
+	app := New("test", "1.0.0", WithModule("http", &envModule{config: defaults}))
+	sample := string(app.SampleConfig())
+
+	for _, expected := range []string{
+		"# Sample configuration of test 1.0.0\n\n# Configuration of the http module\n\n",
+		"# listen_addr (string)\nlisten_addr = \":8080\"\n",
+		"# timeout (duration)\ntimeout = \"5s\"\n",
+		"# hosts (list of string)\n# hosts = []\n",
+		"# tls (block, optional)\n# tls {\n#   cert    = \"\"\n#   enabled = false\n# }\n",
+	} {
+		if !strings.Contains(sample, expected) {
+			t.Errorf("sample configuration does not contain %q:\n%s", expected, sample)
+		}
+	}
+
+	filename := filepath.Join(t.TempDir(), "app.hcl")
+	if err := os.WriteFile(filename, []byte(sample), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &envModule{}
+	if err := New("test", "1.0.0", WithModule("http", m), WithConfigFile(filename)).Validate(context.Background()); err != nil {
+		t.Fatalf("invalid sample configuration: %v\n%s", err, sample)
+	}
+	if !reflect.DeepEqual(m.config, defaults) {
+		t.Errorf("unexpected sample configuration: expected %+v; got %+v", defaults, m.config)
+	}
+
+	for _, opt := range []Option{WithFlags(SampleConfigCommand), WithCommands(SampleConfigCommand)} {
+		var out strings.Builder
+		app = New("test", "1.0.0", WithModule("http", &envModule{config: defaults}), WithConfigFile(filepath.Join(t.TempDir(), "missing.hcl")), opt)
+		app.stdout = &out
+		if err := app.Run(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.String() != sample {
+			t.Errorf("unexpected output of %s:\n%s", SampleConfigCommand, out.String())
+		}
+	}
+
+	// the arguments of the process are only read when flags or commands are enabled
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"test", SampleConfigCommand}
+
+	app = New("test", "1.0.0", WithModule("http", &envModule{}), WithConfigFile(filename))
+	if err := app.parseArgs(app.initialize(context.Background())); err != nil || app.command() != "" {
+		t.Errorf("unexpected command %q: %v", app.command(), err)
+	}
+}
+
+type describedConfig struct {
+	Listen  string          `config:"listen" description:"The address to listen on"`
+	Timeout time.Duration   `config:"timeout,default=5s" description:"How long | to wait"`
+	Hosts   []string        `config:"hosts,optional"`
+	TLS     *describedTLS   `config:"tls,block" description:"Serves HTTPS."`
+	Routes  []describedPath `config:"route,block"`
+}
+
+type describedTLS struct {
+	Cert   string          `config:"cert" description:"The certificate file"`
+	Client describedClient `config:"client,block"`
+}
+
+type describedClient struct {
+	CA string `config:"ca,optional"`
+}
+
+type describedPath struct {
+	Path    string `config:"path,label"`
+	Backend string `config:"backend"`
+}
+
+type describedModule struct {
+	config describedConfig
+}
+
+func (m *describedModule) Start(context.Context) error { return nil }
+func (m *describedModule) Stop(context.Context) error  { return nil }
+
+func (m *describedModule) Config() (interface{}, error) {
+	return &m.config, nil
+}
+
+func TestConfigReference(t *testing.T) {
+	app := New("test", "1.0.0", WithModule("http", &describedModule{}), WithEnvOverrides("APP"))
+
+	reference := string(app.ConfigReference())
+	for _, expected := range []string{
+		"# test configuration\n\n",
+		"\n## `http` module\n\n| Name | Type | Go type | Required | Default | Description |\n| --- | --- | --- | --- | --- | --- |\n",
+		"| `listen` | string | `string` | yes |  | The address to listen on |\n",
+		"| `timeout` | duration | `time.Duration` | no | `5s` | How long \\| to wait |\n",
+		"| `hosts` | list of string | `[]string` | no |  |  |\n",
+		"\n### `tls` block\n\nOptional. Serves HTTPS.\n\n| Name |",
+		"| `cert` | string | `string` | yes |  | The certificate file |\n",
+		"\n#### `tls.client` block\n\nRequired.\n\n",
+		"\n### `route` block\n\nAny number of blocks. Labeled by `path`.\n\n",
+	} {
+		if !strings.Contains(reference, expected) {
+			t.Errorf("reference does not contain %q:\n%s", expected, reference)
+		}
+	}
+
+	sample := string(app.SampleConfig())
+	if expected := "# The address to listen on\n# listen (string, required)\nlisten = \"\"\n"; !strings.Contains(sample, expected) {
+		t.Errorf("sample configuration does not contain %q:\n%s", expected, sample)
+	}
+
+	vars := app.EnvVars()
+	if len(vars) < 2 || vars[1].Name != "APP_HTTP_LISTEN" || vars[1].Description != "The address to listen on" {
+		t.Errorf("unexpected environment variables: %+v", vars)
+	}
+
+	schema := confighcl.ImpliedJSONSchema(&describedConfig{})
+	if schema.Properties["listen"].Description != "The address to listen on" || schema.Properties["tls"].Description != "Serves HTTPS." {
+		t.Errorf("schema does not include descriptions: %+v", schema.Properties)
+	}
+}
+
+type handlerLevel int
+
+type handlerHostPort struct {
+	Host string
+	Port string
+}
+
+func (a *handlerHostPort) UnmarshalText(text []byte) error {
+	host, port, ok := strings.Cut(string(text), ":")
+	if !ok {
+		return errors.New("missing port")
+	}
+
+	a.Host, a.Port = host, port
+	return nil
+}
+
+func (a handlerHostPort) MarshalText() ([]byte, error) {
+	return []byte(a.Host + ":" + a.Port), nil
+}
+
+// Duration is not a time.Duration, so it is decoded as a number
+type Duration int
+
+type handlerConfig struct {
+	Level    handlerLevel    `config:"level,optional"`
+	Addr     handlerHostPort `config:"addr,optional"`
+	Timeout  *time.Duration  `config:"timeout,optional"`
+	Interval Duration        `config:"interval,optional"`
+}
+
+func init() {
+	confighcl.RegisterType(reflect.TypeOf(handlerLevel(0)), confighcl.TypeHandler{
+		Type: cty.String,
+		Name: "level",
+		Decode: func(val cty.Value) (interface{}, error) {
+			switch val.AsString() {
+			case "low":
+				return handlerLevel(1), nil
+			case "high":
+				return handlerLevel(2), nil
+			}
+			return nil, errors.New("expected low or high")
+		},
+		Encode: func(v interface{}) (cty.Value, error) {
+			return cty.StringVal(map[handlerLevel]string{1: "low", 2: "high"}[v.(handlerLevel)]), nil
+		},
+	})
+}
+
+func TestTypeHandlers(t *testing.T) {
+	cfg := &Configuration{}
+
+	file, diags := cfg.Parse("test.hcl", []byte(`
+level    = "high"
+addr     = "localhost:8080"
+timeout  = "5s"
+interval = 10
+`))
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	var value handlerConfig
+	if diags := confighcl.DecodeBody(file.Body, cfg.EvalContext(context.Background()), &value); diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	timeout := 5 * time.Second
+	expected := handlerConfig{Level: 2, Addr: handlerHostPort{Host: "localhost", Port: "8080"}, Timeout: &timeout, Interval: 10}
+	if !reflect.DeepEqual(value, expected) {
+		t.Errorf("unexpected configuration: expected %+v; got %+v", expected, value)
+	}
+
+	f := hclwrite.NewEmptyFile()
+	confighcl.EncodeIntoBody(&value, f.Body())
+	for _, expected := range []string{`level    = "high"`, `addr     = "localhost:8080"`, `timeout  = "5s"`, `interval = 10`} {
+		if !strings.Contains(string(f.Bytes()), expected) {
+			t.Errorf("encoded configuration does not contain %q:\n%s", expected, f.Bytes())
+		}
+	}
+
+	var types []string
+	for _, field := range confighcl.Fields(&value) {
+		types = append(types, field.Type)
+	}
+	if expected := []string{"string", "number", "level", "duration"}; !reflect.DeepEqual(types, expected) {
+		t.Errorf("unexpected field types: expected %v; got %v", expected, types)
+	}
+
+	for _, test := range []struct {
+		Input  string
+		Detail string
+	}{
+		{Input: `level = "medium"`, Detail: "The level is invalid: expected low or high."},
+		{Input: `addr = "localhost"`, Detail: "The string is invalid: missing port."},
+		{Input: `timeout = "5 seconds"`, Detail: `The duration is invalid: unknown unit " seconds" in duration "5 seconds".`},
+		{Input: `timeout = ["5s"]`, Detail: "Unsuitable value: string required"},
+	} {
+		file, diags := cfg.Parse("test.hcl", []byte(test.Input))
+		if diags.HasErrors() {
+			t.Fatal(diags.Error())
+		}
+
+		diags = confighcl.DecodeBody(file.Body, nil, &handlerConfig{})
+		if len(diags) != 1 || diags[0].Detail != test.Detail {
+			t.Errorf("unexpected diagnostics of %s: expected %q; got %v", test.Input, test.Detail, diags)
+		}
+	}
+}
+
+type builtinConfig struct {
+	Size     confighcl.ByteSize `config:"size,optional"`
+	IP       net.IP             `config:"ip,optional"`
+	Addr     netip.Addr         `config:"addr,optional"`
+	Prefix   netip.Prefix       `config:"prefix,optional"`
+	Network  *net.IPNet         `config:"network,optional"`
+	Endpoint *url.URL           `config:"endpoint,optional"`
+	Pattern  *regexp.Regexp     `config:"pattern,optional"`
+	Mode     os.FileMode        `config:"mode,optional"`
+	Since    time.Time          `config:"since,optional"`
+}
+
+func TestBuiltinTypes(t *testing.T) {
+	cfg := &Configuration{}
+
+	src := `size     = "512MiB"
+ip       = "10.0.0.1"
+addr     = "::1"
+prefix   = "10.0.0.0/8"
+network  = "192.168.0.0/16"
+endpoint = "https://example.com/path?q=1"
+pattern  = "^[a-z]+$"
+mode     = "04755"
+since    = "2024-01-02T15:04:05Z"
+`
+
+	file, diags := cfg.Parse("test.hcl", []byte(src))
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	var value builtinConfig
+	if diags := confighcl.DecodeBody(file.Body, nil, &value); diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	_, network, _ := net.ParseCIDR("192.168.0.0/16")
+	expected := builtinConfig{
+		Size:     512 << 20,
+		IP:       net.ParseIP("10.0.0.1"),
+		Addr:     netip.MustParseAddr("::1"),
+		Prefix:   netip.MustParsePrefix("10.0.0.0/8"),
+		Network:  network,
+		Endpoint: &url.URL{Scheme: "https", Host: "example.com", Path: "/path", RawQuery: "q=1"},
+		Pattern:  regexp.MustCompile("^[a-z]+$"),
+		Mode:     0o755 | os.ModeSetuid,
+		Since:    time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(value, expected) {
+		t.Errorf("unexpected configuration: expected %+v; got %+v", expected, value)
+	}
+
+	f := hclwrite.NewEmptyFile()
+	confighcl.EncodeIntoBody(&value, f.Body())
+	if encoded := string(f.Bytes()); encoded != src {
+		t.Errorf("unexpected encoded configuration:\n%s", encoded)
+	}
+
+	f = hclwrite.NewEmptyFile()
+	confighcl.EncodeIntoBody(&builtinConfig{}, f.Body())
+	if encoded := string(f.Bytes()); !strings.Contains(encoded, "addr   = null\n") || !strings.Contains(encoded, `mode   = "0"`) {
+		t.Errorf("unexpected encoded zero values:\n%s", encoded)
+	}
+
+	for _, test := range []struct {
+		Input  string
+		Detail string
+	}{
+		{Input: `size = "12 parsecs"`, Detail: `The byte size is invalid: "12 parsecs" has an unknown unit "parsecs", such as B, kB, MB, KiB or MiB.`},
+		{Input: `size = "99999EiB"`, Detail: `The byte size is invalid: "99999EiB" is larger than 18446744073709551615 bytes.`},
+		{Input: `ip = "10.0.0"`, Detail: `The IP address is invalid: "10.0.0" is not an IPv4 or IPv6 address.`},
+		{Input: `addr = "10.0.0.256"`, Detail: `The IP address is invalid: "10.0.0.256" is not an IPv4 or IPv6 address: IPv4 field has value >255.`},
+		{Input: `prefix = "10.0.0.0"`, Detail: `The CIDR prefix is invalid: "10.0.0.0" is not a CIDR prefix such as 10.0.0.0/8: no '/'.`},
+		{Input: `endpoint = "http://[::1"`, Detail: `The URL is invalid: "http://[::1" is not a URL: missing ']' in host.`},
+		{Input: `pattern = "("`, Detail: "The regular expression is invalid: missing closing ): `(`."},
+		{Input: `mode = "0999"`, Detail: `The file mode is invalid: "0999" is not an octal file mode such as 0644.`},
+		{Input: `since = "yesterday"`, Detail: `The timestamp is invalid: "yesterday" is not an RFC 3339 timestamp such as 2006-01-02T15:04:05Z.`},
+	} {
+		file, diags := cfg.Parse("test.hcl", []byte(test.Input))
+		if diags.HasErrors() {
+			t.Fatal(diags.Error())
+		}
+
+		diags = confighcl.DecodeBody(file.Body, nil, &builtinConfig{})
+		if len(diags) != 1 || diags[0].Detail != test.Detail || diags[0].Subject.Start.Column != strings.Index(test.Input, `"`)+2 {
+			t.Errorf("unexpected diagnostics of %s: expected %q; got %v", test.Input, test.Detail, diags)
+		}
+	}
+
+	for input, expected := range map[string]confighcl.ByteSize{"1024": 1024, "1.5 GB": 1500000000, "2kib": 2048, "0.5KiB": 512} {
+		if size, err := confighcl.ParseByteSize(input); err != nil || size != expected {
+			t.Errorf("unexpected size of %s: expected %d; got %d (%v)", input, expected, size, err)
+		}
+	}
+	if s := confighcl.ByteSize(1500000000).String(); s != "1500MB" {
+		t.Errorf("unexpected byte size string %s", s)
+	}
+}
+
+type collectionsConfig struct {
+	Prefixes  []netip.Prefix      `config:"prefixes,optional" validate:"min=1"`
+	Addrs     []*netip.Addr       `config:"addrs,optional"`
+	Endpoints map[string]*url.URL `config:"endpoints,optional"`
+}
+
+func TestBuiltinCollections(t *testing.T) {
+	cfg := &Configuration{}
+
+	src := `prefixes  = ["10.0.0.0/8", "192.168.0.0/16"]
+addrs     = ["::1"]
+endpoints = {
+  api = "https://api.example.com"
+}
+`
+
+	file, diags := cfg.Parse("test.hcl", []byte(src))
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	var value collectionsConfig
+	if diags := confighcl.DecodeBody(file.Body, nil, &value); diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	addr := netip.MustParseAddr("::1")
+	expected := collectionsConfig{
+		Prefixes:  []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.168.0.0/16")},
+		Addrs:     []*netip.Addr{&addr},
+		Endpoints: map[string]*url.URL{"api": {Scheme: "https", Host: "api.example.com"}},
+	}
+	if !reflect.DeepEqual(value, expected) {
+		t.Errorf("unexpected configuration: expected %+v; got %+v", expected, value)
+	}
+
+	f := hclwrite.NewEmptyFile()
+	confighcl.EncodeIntoBody(&value, f.Body())
+	if file, diags = cfg.Parse("encoded.hcl", f.Bytes()); diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	var decoded collectionsConfig
+	if diags := confighcl.DecodeBody(file.Body, nil, &decoded); diags.HasErrors() || !reflect.DeepEqual(decoded, expected) {
+		t.Errorf("unexpected decoded configuration: %+v %v\n%s", decoded, diags, f.Bytes())
+	}
+
+	file, diags = cfg.Parse("test.hcl", []byte(`prefixes = ["10.0.0.0/8", "10.0.0.0"]`))
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	diags = confighcl.DecodeBody(file.Body, nil, &collectionsConfig{})
+	if detail := `The list of CIDR prefix is invalid: element 1: "10.0.0.0" is not a CIDR prefix such as 10.0.0.0/8: no '/'.`; len(diags) != 1 || diags[0].Detail != detail {
+		t.Errorf("unexpected diagnostics: expected %q; got %v", detail, diags)
+	}
+
+	var types []string
+	for _, field := range confighcl.Fields(&collectionsConfig{}) {
+		types = append(types, field.Type)
+	}
+	if expected := []string{"list of IP address", "map of URL", "list of CIDR prefix"}; !reflect.DeepEqual(types, expected) {
+		t.Errorf("unexpected field types: expected %v; got %v", expected, types)
+	}
+
+	b, err := json.Marshal(confighcl.ImpliedJSONSchema(&collectionsConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if prefixes := schemaPath(t, schema, "properties", "prefixes"); prefixes["minItems"] != 1.0 {
+		t.Errorf("unexpected schema of prefixes: %v", prefixes)
+	}
+	if endpoint := schemaPath(t, schema, "properties", "endpoints", "additionalProperties"); endpoint["format"] != "uri-reference" {
+		t.Errorf("unexpected schema of the endpoints: %v", endpoint)
+	}
+}
+
+func TestModuleBlocks(t *testing.T) {
+	write := func(t *testing.T, src string) string {
+		filename := filepath.Join(t.TempDir(), "app.hcl")
+		if err := os.WriteFile(filename, []byte(src), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+
+	newApp := func(filename string, public, admin, server *envModule) *Application {
+		return New("test", "1.0.0",
+			WithModule("http.public", public),
+			WithModule("http.admin", admin),
+			WithModule("server", server),
+			WithModuleBlocks(),
+			WithConfigFile(filename),
+		)
+	}
+
+	public, admin, server := &envModule{}, &envModule{}, &envModule{config: envConfig{ListenAddr: ":1"}}
+	filename := write(t, `
+http "public" {
+  listen_addr = ":8080"
+}
+
+module "http.admin" {
+  listen_addr = ":9090"
+
+  tls {
+    cert = "admin.pem"
+  }
+}
+`)
+	if err := newApp(filename, public, admin, server).Validate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if public.config.ListenAddr != ":8080" || public.config.TLS != nil {
+		t.Errorf("unexpected public configuration: %+v", public.config)
+	}
+	if admin.config.ListenAddr != ":9090" || admin.config.TLS == nil || admin.config.TLS.Cert != "admin.pem" {
+		t.Errorf("unexpected admin configuration: %+v", admin.config)
+	}
+	if server.config.ListenAddr != ":1" {
+		t.Errorf("unexpected server configuration without a block: %+v", server.config)
+	}
+
+	for _, test := range []struct {
+		Input string
+		Error string
+	}{
+		{Input: `module "ftp" {}`, Error: `There is no configurable module named "ftp".`},
+		{Input: `http "private" {}`, Error: `There is no configurable module named "http.private".`},
+		{Input: "server {}\nmodule \"server\" {}", Error: "The server module was already configured at"},
+		{Input: `listen_addr = ":8080"`, Error: `An argument named "listen_addr" is not expected here.`},
+		{Input: "http {}", Error: "Missing name for http"},
+	} {
+		err := newApp(write(t, test.Input), &envModule{}, &envModule{}, &envModule{}).Validate(context.Background())
+		if err == nil || !strings.Contains(err.Error(), test.Error) {
+			t.Errorf("unexpected error of %s: expected %q; got %v", test.Input, test.Error, err)
+		}
+	}
+
+	app := newApp(filename, &envModule{}, &envModule{}, &envModule{config: envConfig{ListenAddr: ":1"}})
+	sample := string(app.SampleConfig())
+	for _, expected := range []string{
+		"# Configuration of the http.public module\n\nhttp \"public\" {\n",
+		"# Configuration of the server module\n\nserver {\n  # listen_addr (string)\n  listen_addr = \":1\"\n",
+	} {
+		if !strings.Contains(sample, expected) {
+			t.Errorf("sample configuration does not contain %q:\n%s", expected, sample)
+		}
+	}
+
+	if err := newApp(write(t, sample), &envModule{}, &envModule{}, &envModule{}).Validate(context.Background()); err != nil {
+		t.Errorf("invalid sample configuration: %v\n%s", err, sample)
+	}
+
+	data, err := app.JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	if ref := schemaPath(t, schema, "properties", "server")["$ref"]; ref != "#/$defs/server" {
+		t.Errorf("unexpected server property: %v", ref)
+	}
+	if ref := schemaPath(t, schema, "properties", "http", "properties", "admin")["$ref"]; ref != "#/$defs/http.admin" {
+		t.Errorf("unexpected http admin property: %v", ref)
+	}
+	if ref := schemaPath(t, schema, "properties", "module", "properties", "http.public")["$ref"]; ref != "#/$defs/http.public" {
+		t.Errorf("unexpected module property: %v", ref)
+	}
+	if schema["additionalProperties"] != false || schemaPath(t, schema, "properties", "//") == nil {
+		t.Errorf("unexpected additional properties: %v", schema["additionalProperties"])
+	}
+
+	reference := string(app.ConfigReference())
+	if !strings.Contains(reference, "## `http.admin` module\n\nConfigured by the `http \"admin\"` block, or the `module \"http.admin\"` block.\n") {
+		t.Errorf("unexpected reference:\n%s", reference)
+	}
+}
+
+func TestUnknownKeys(t *testing.T) {
+	for _, test := range []struct {
+		Name   string
+		Input  string
+		Blocks bool
+		Detail string
+	}{
+		{Name: "app.hcl", Input: "prot = 80", Detail: `An argument named "prot" is not expected here. Did you mean "port" of the server module?`},
+		{Name: "app.hcl", Input: `listen_adr = ":80"`, Detail: `Did you mean "listen_addr" of the http module?`},
+		{Name: "app.hcl", Input: "tsl {}", Detail: `Blocks of type "tsl" are not expected here. Did you mean "tls" of the http module?`},
+		{Name: "app.hcl", Input: "unrelated = true", Detail: `An argument named "unrelated" is not expected here.`},
+		{Name: "app.json", Input: `{"prot": 80}`, Detail: `No argument or block type is named "prot". Did you mean "port" of the server module?`},
+		{Name: "app.json", Input: `{"pr\u006ft": 80}`, Detail: `Did you mean "port" of the server module?`},
+		{Name: "app.yaml", Input: "prot: 80", Detail: `Did you mean "port" of the server module?`},
+		{Name: "app.yaml", Input: "'tsl': {}", Detail: `Did you mean "tls" of the http module?`},
+		{Name: "app.toml", Input: `"listen_adr" = ":80"`, Detail: `Did you mean "listen_addr" of the http module?`},
+		{Name: "app.hcl", Input: "htp {}", Blocks: true, Detail: `Did you mean "http"?`},
+		{Name: "app.hcl", Input: "http {\n  listen_adr = \":80\"\n}", Blocks: true, Detail: `Did you mean "listen_addr" of the http module?`},
+	} {
+		filename := filepath.Join(t.TempDir(), test.Name)
+		if err := os.WriteFile(filename, []byte(test.Input), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		options := []Option{WithModule("http", &envModule{}), WithModule("server", &validateModule{}), WithConfigFile(filename)}
+		if test.Blocks {
+			options = append(options, WithModuleBlocks())
+		}
+
+		err := New("test", "1.0.0", options...).Validate(context.Background())
+		if err == nil || !strings.HasSuffix(err.Error(), test.Detail) {
+			t.Errorf("unexpected error of %s: expected %q; got %v", test.Input, test.Detail, err)
+		}
+
+		var logs strings.Builder
+		options = append(options, WithUnknownKeyWarnings(), WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+		if err := New("test", "1.0.0", options...).Validate(context.Background()); err != nil {
+			t.Errorf("unexpected error of %s with unknown key warnings: %v", test.Input, err)
+		}
+		if !strings.Contains(logs.String(), "level=WARN msg=\"Configuration warning\"") {
+			t.Errorf("unknown key of %s was not logged:\n%s", test.Input, logs.String())
+		}
+	}
+
+	filename := filepath.Join(t.TempDir(), "app.hcl")
+	if err := os.WriteFile(filename, []byte("http {\n  listen_adr  = \":80\"\n  listen_addr = \":8080\"\n}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &envModule{}
+	app := New("test", "1.0.0", WithModule("http", m), WithModuleBlocks(), WithUnknownKeyWarnings(), WithConfigFile(filename), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	if err := app.Validate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.config.ListenAddr != ":8080" {
+		t.Errorf("unexpected configuration: %+v", m.config)
+	}
+}
+
+func TestVariables(t *testing.T) {
+	write := func(t *testing.T, src string) string {
+		filename := filepath.Join(t.TempDir(), "app.hcl")
+		if err := os.WriteFile(filename, []byte(src), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+
+	t.Setenv("APP_VAR_PORT", "8080")
+
+	server, http := &validateModule{}, &envModule{}
+	filename := write(t, `
+variable "region" {
+  default = "eu"
+}
+
+variable "port" {
+  description = "The port to listen on"
+}
+
+locals {
+  host = "${local.name}.example.com"
+  name = "app-${var.region}"
+}
+
+server {
+  listen = module.http.listen_addr
+  hosts  = module["http"].hosts
+}
+
+http {
+  listen_addr = "${local.host}:${var.port}"
+  hosts       = [local.host]
+}
+`)
+	app := New("test", "1.0.0", WithModule("server", server), WithModule("http", http), WithModuleBlocks(), WithEnvOverrides("APP"), WithConfigFile(filename))
+	if err := app.Validate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if http.config.ListenAddr != "app-eu.example.com:8080" || !reflect.DeepEqual(http.config.Hosts, []string{"app-eu.example.com"}) {
+		t.Errorf("unexpected http configuration: %+v", http.config)
+	}
+	if server.config.Listen != http.config.ListenAddr || !reflect.DeepEqual(server.config.Hosts, http.config.Hosts) {
+		t.Errorf("unexpected server configuration: %+v", server.config)
+	}
+
+	for _, test := range []struct {
+		Input string
+		Error string
+	}{
+		{Input: `variable "region" {}`, Error: `Missing variable value; The variable "region" has no default value.`},
+		{Input: "variable \"region\" {\n  default = 1\n}\nvariable \"region\" {\n  default = 2\n}", Error: `A variable named "region" was already declared at`},
+		{Input: "locals {\n  a = local.b\n  b = local.a\n}", Error: `Cycle in local values; The local value "a" refers to itself: local.a -> local.b -> local.a.`},
+		{Input: "locals {\n  a = local.b\n}", Error: `Unsupported attribute; This object does not have an attribute named "b".`},
+		{Input: "listen      = module.http.listen_addr\nlisten_addr = module.server.listen", Error: "Cycle in module references; The configuration of the server module refers to itself: server -> http -> server."},
+		{Input: "listen_addr = module.ftp.listen_addr", Error: `app.hcl:1,15-25: Reference to undeclared module; There is no configurable module named "ftp".`},
+		{Input: "locals {\n  id = uuid()\n}", Error: `Call to function "uuid" failed: uuid returns a different value every time it is called`},
+		{Input: "listen      = module.http.listen_addr\nlisten_addr = uuid()", Error: `Call to function "uuid" failed: uuid returns a different value every time it is called`},
+	} {
+		err := New("test", "1.0.0", WithModule("server", &validateModule{}), WithModule("http", &envModule{}), WithConfigFile(write(t, test.Input))).Validate(context.Background())
+		if err == nil || !strings.Contains(err.Error(), test.Error) {
+			t.Errorf("unexpected error of %s: expected %q; got %v", test.Input, test.Error, err)
+		}
+	}
+
+	// modules that no module refers to can use impure functions
+	uuidModule := &envModule{}
+	if err := New("test", "1.0.0", WithModule("http", uuidModule), WithConfigFile(write(t, "listen_addr = uuid()"))).Validate(context.Background()); err != nil || len(uuidModule.config.ListenAddr) != 36 {
+		t.Errorf("unexpected configuration using uuid: %+v %v", uuidModule.config, err)
+	}
+
+	value := confighcl.EncodeValue(&envConfig{ListenAddr: ":80", TLS: &envTLSConfig{Cert: "cert.pem"}})
+	expected := cty.ObjectVal(map[string]cty.Value{
+		"listen_addr": cty.StringVal(":80"),
+		"timeout":     cty.StringVal("0s"),
+		"hosts":       cty.NullVal(cty.List(cty.String)),
+		"tls":         cty.ObjectVal(map[string]cty.Value{"cert": cty.StringVal("cert.pem"), "enabled": cty.False}),
+	})
+	if !value.RawEquals(expected) {
+		t.Errorf("unexpected encoded value: expected %#v; got %#v", expected, value)
+	}
+}
+
+type secretConfig struct {
+	User     string            `config:"user,optional"`
+	Password confighcl.Secret  `config:"password,optional"`
+	Token    *confighcl.Secret `config:"token,optional"`
+}
+
+type secretModule struct {
+	config secretConfig
+}
+
+func (m *secretModule) Start(context.Context) error { return nil }
+func (m *secretModule) Stop(context.Context) error  { return nil }
+
+func (m *secretModule) Config() (interface{}, error) {
+	return &m.config, nil
+}
+
+type mapSecrets map[string]string
+
+func (s mapSecrets) Secret(_ context.Context, name string) (string, error) {
+	if value, ok := s[name]; ok {
+		return value, nil
+	}
+	return "", fmt.Errorf("secret %q does not exist", name)
+}
+
+func TestSecrets(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.hcl")
+	write := func(src string) {
+		if err := os.WriteFile(filename, []byte(src), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`
+locals {
+  token = vault("token")
+}
+
+user     = "admin"
+password = vault("db/password")
+token    = "${local.token}-suffix"
+`)
+
+	m := &secretModule{}
+	provider := mapSecrets{"db/password": "hunter2", "token": "t0k3n"}
+	app := New("test", "1.0.0", WithModule("db", m), WithSecretProvider("vault", provider), WithConfigFile(filename))
+	if err := app.Validate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(m.config.Password) != "hunter2" || m.config.Token == nil || string(*m.config.Token) != "t0k3n-suffix" {
+		t.Errorf("unexpected secrets: %q, %v", string(m.config.Password), m.config.Token)
+	}
+
+	var logs strings.Builder
+	slog.New(slog.NewTextHandler(&logs, nil)).Info("config", "password", m.config.Password)
+	for _, printed := range []string{fmt.Sprintf("%v %+v %#v", m.config, m.config, m.config), logs.String()} {
+		if strings.Contains(printed, "hunter2") || !strings.Contains(printed, "REDACTED") {
+			t.Errorf("secret was not redacted: %s", printed)
+		}
+	}
+
+	f := hclwrite.NewEmptyFile()
+	confighcl.EncodeIntoBody(&m.config, f.Body())
+	if encoded := string(f.Bytes()); encoded != "user = \"admin\"\n" {
+		t.Errorf("unexpected encoded configuration:\n%s", encoded)
+	}
+
+	if sample := string(app.SampleConfig()); !strings.Contains(sample, "# password (secret)\n# password = \"\"\n") || strings.Contains(sample, "hunter2") {
+		t.Errorf("unexpected sample configuration:\n%s", sample)
+	}
+
+	for _, test := range []struct {
+		Input string
+		Error string
+	}{
+		{Input: `user = vault("db/password")`, Error: "Unsuitable secret value; The value is a secret, which can only be used for attributes of type secret"},
+		{Input: `user = upper(vault("db/password"))`, Error: "Unsuitable secret value"},
+		{Input: `password = vault("missing")`, Error: `Call to function "vault" failed: secret "missing" does not exist.`},
+	} {
+		write(test.Input)
+
+		err := New("test", "1.0.0", WithModule("db", &secretModule{}), WithSecretProvider("vault", provider), WithConfigFile(filename)).Validate(context.Background())
+		if err == nil || !strings.Contains(err.Error(), test.Error) {
+			t.Errorf("unexpected error of %s: expected %q; got %v", test.Input, test.Error, err)
+		}
+		if err != nil && strings.Contains(err.Error(), "hunter2") {
+			t.Errorf("error of %s contains the secret: %v", test.Input, err)
+		}
+	}
+}
+
+func TestFuncs(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"a/app.hcl":    `listen = trimspace(file("listen.txt"))`,
+		"a/listen.txt": ":8080\n",
+		"b/more.hcl":   "locals {\n  host = file(\"host.txt\")\n}\nhosts = [local.host, templatefile(\"host.tmpl\", {})]",
+		"b/host.txt":   "b",
+		"b/host.tmpl":  "${file(\"host.txt\")}",
+	} {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// each file resolves relative paths from its own directory
+	m := &reloadModule{}
+	app := New("test", "1.0.0", WithConfigFile(filepath.Join(dir, "a", "app.hcl"), filepath.Join(dir, "b", "more.hcl")), WithModule("http", m))
+	if err := app.Validate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := reloadConfig{Listen: ":8080", Hosts: []string{"b", "b"}}
+	if !reflect.DeepEqual(m.config, expected) {
+		t.Errorf("unexpected configuration: expected %+v; got %+v", expected, m.config)
+	}
+}
@@ -2,28 +2,62 @@ package application
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"reflect"
 	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
+var (
+	// ErrMissingDependency is returned when a module declares a dependency on a module that has not been added
+	ErrMissingDependency = errors.New("missing module dependency")
+
+	// ErrDependencyCycle is returned when module dependencies form a cycle
+	ErrDependencyCycle = errors.New("module dependency cycle")
+
+	// ErrTimeout is returned when a module exceeds the timeout of a lifecycle phase
+	ErrTimeout = errors.New("timed out")
+)
+
 // Controller for modules
 type Controller struct {
+	// Concurrent runs the Install, PreStart, Start, PostStart and Stop of modules without a dependency relationship in
+	// parallel, each phase still completes for every module before the next begins. Initialize is always run serially.
+	Concurrent bool
+
+	// Timeouts limit how long each module may take in a lifecycle phase, modules implementing Timeouter can override them.
+	// When a module exceeds the timeout its context is cancelled and the Controller continues with an ErrTimeout, a module
+	// whose Start timed out is still stopped. The contexts of PreStart and Stop are also cancelled when the module returns,
+	// those of the other phases live on so modules can tie background work to them.
+	Timeouts map[Phase]time.Duration
+
+	// HealthTimeout limits how long each module health check may take, DefaultHealthTimeout is used when zero
+	HealthTimeout time.Duration
+
 	modules map[string]*moduleReference
+	lock    sync.RWMutex
 	orderer *int64
 	once    sync.Once
 	logger  *slog.Logger
+	ready   atomic.Bool
+	events  subscribers
 }
 
 type moduleReference struct {
 	name           string
-	started        bool
 	order          int64
 	implementation Module
+
+	lock        sync.Mutex
+	started     bool
+	checking    atomic.Bool
+	timings     map[Phase]time.Duration
+	transitions []Transition
 }
 
 func (c *Controller) init() {
@@ -47,11 +81,17 @@ func (c *Controller) Add(name string, m Module) {
 		return
 	}
 
-	c.modules[name] = &moduleReference{
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	rm := &moduleReference{
 		name:           name,
 		implementation: m,
 		order:          atomic.AddInt64(c.orderer, 1),
 	}
+	rm.transition(StateRegistered, nil)
+
+	c.modules[name] = rm
 }
 
 // Remove the specified module
@@ -62,6 +102,9 @@ func (c *Controller) Remove(name string) {
 		return
 	}
 
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
 	delete(c.modules, name)
 }
 
@@ -73,6 +116,9 @@ func (c *Controller) Get(name string) Module {
 		return nil
 	}
 
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
 	ref, found := c.modules[name]
 	if !found {
 		return nil
@@ -81,9 +127,14 @@ func (c *Controller) Get(name string) Module {
 	return ref.implementation
 }
 
-// Range over the modules
+// Range over the modules in dependency order, if the dependencies cannot be resolved the modules are ranged in the order they were added
 func (c *Controller) Range(cb func(name string, module Module) bool) {
-	sorted := sortModules(c.modules)
+	c.lock.RLock()
+	sorted, err := sortModules(c.modules)
+	if err != nil {
+		sorted = orderModules(c.modules)
+	}
+	c.lock.RUnlock()
 
 	for _, m := range sorted {
 		if !cb(m.name, m.implementation) {
@@ -92,17 +143,24 @@ func (c *Controller) Range(cb func(name string, module Module) bool) {
 	}
 }
 
-// Run the added modules. This will run the lifetime on modules in the order they were added
+// Validate ensures the dependencies declared by modules implementing Dependent can be resolved
+func (c *Controller) Validate() error {
+	_, err := c.sorted()
+	return err
+}
+
+// Run the added modules. This will run the lifetime on modules in dependency order, modules without a dependency relationship run in the order they were added
 //
 // Module lifetime is called in the following order:
 // * if module is Initializer -> Initialize()
 // * if module is PreStarter -> PreStart()
 // * Start()
 // * if module is PostStarter -> PostStart()
-// * wait for context.Done()
+// * wait for context.Done(), restarting failed Supervised modules
 // * Stop()
 //
-// Stop() will be called on all module that Start() was successfully called on, even during error
+// Stop() will be called on all module that Start() was successfully called on or that exceeded the Start() timeout, even during error.
+// When Concurrent is set, each phase runs modules in parallel as soon as their dependencies completed the phase.
 func (c *Controller) Run(ctx context.Context) error {
 	c.once.Do(c.init)
 
@@ -115,13 +173,21 @@ func (c *Controller) Run(ctx context.Context) error {
 	sts := time.Now()
 	c.logger.Debug("Module controller intializations starting")
 
-	// build a list of modules so we can run them in the correct ordering (as added)
-	runModules := sortModules(c.modules)
+	// build a list of modules so we can run them in the correct ordering (dependencies, then as added)
+	runModules, err := c.sorted()
+	if err != nil {
+		return exitErr.Append(err).Err()
+	}
+
 	for _, rm := range runModules {
 		if initializer, ok := rm.implementation.(Initializer); ok {
 			ts := time.Now()
 			c.logger.Debug("Initializing module", "module", rm.name)
-			itx, err := initializer.Initialize(ctx)
+			var itx context.Context
+			err := c.call(ctx, rm, PhaseInitialize, func(ctx context.Context) (err error) {
+				itx, err = initializer.Initialize(ctx)
+				return err
+			})
 			if err != nil {
 				exitErr = exitErr.Append(fmt.Errorf("failed to initialize module %q: %w", rm.name, err))
 				return exitErr.Err()
@@ -131,92 +197,133 @@ func (c *Controller) Run(ctx context.Context) error {
 				ctx = itx
 			}
 			c.logger.Debug("Initialized module", "module", rm.name, "duration", time.Since(ts))
+		} else {
+			rm.transition(StateInitialized, nil)
 		}
 	}
 
 	// account for any modules added in Initialize
-	runModules = sortModules(c.modules)
-	for _, rm := range runModules {
-		if installer, ok := rm.implementation.(Installer); ok {
-			ts := time.Now()
-			c.logger.Debug("Installing module", "module", rm.name)
-			err := installer.Install(ctx)
-			if err != nil {
-				exitErr = exitErr.Append(fmt.Errorf("failed to install module %q: %w", rm.name, err))
-				goto shutdown
-			}
-			c.logger.Debug("Installed module", "module", rm.name, "duration", time.Since(ts))
+	if runModules, err = c.sorted(); err != nil {
+		exitErr = exitErr.Append(err)
+		goto shutdown
+	}
+	if err := c.each(runModules, false, func(rm *moduleReference) error {
+		installer, ok := rm.implementation.(Installer)
+		if !ok {
+			rm.transition(StateInstalled, nil)
+			return nil
 		}
+
+		ts := time.Now()
+		c.logger.Debug("Installing module", "module", rm.name)
+		if err := c.call(ctx, rm, PhaseInstall, installer.Install); err != nil {
+			return fmt.Errorf("failed to install module %q: %w", rm.name, err)
+		}
+		c.logger.Debug("Installed module", "module", rm.name, "duration", time.Since(ts))
+
+		return nil
+	}); err != nil {
+		exitErr = exitErr.Append(err)
+		goto shutdown
 	}
 
 	// account for any modules added in Initialize
-	runModules = sortModules(c.modules)
-	for _, rm := range runModules {
-		if prestarter, ok := rm.implementation.(PreStarter); ok {
-			ts := time.Now()
-			c.logger.Debug("PreStarting module", "module", rm.name)
-			if err := prestarter.PreStart(ctx); err != nil {
-				exitErr = exitErr.Append(fmt.Errorf("failed to prestart module %q: %w", rm.name, err))
-				goto shutdown
-			}
-			c.logger.Debug("PreStarted module", "module", rm.name, "duration", time.Since(ts))
+	if runModules, err = c.sorted(); err != nil {
+		exitErr = exitErr.Append(err)
+		goto shutdown
+	}
+	if err := c.each(runModules, false, func(rm *moduleReference) error {
+		prestarter, ok := rm.implementation.(PreStarter)
+		if !ok {
+			rm.transition(StatePreStarted, nil)
+			return nil
+		}
+
+		ts := time.Now()
+		c.logger.Debug("PreStarting module", "module", rm.name)
+		if err := c.call(ctx, rm, PhasePreStart, prestarter.PreStart); err != nil {
+			return fmt.Errorf("failed to prestart module %q: %w", rm.name, err)
 		}
+		c.logger.Debug("PreStarted module", "module", rm.name, "duration", time.Since(ts))
+
+		return nil
+	}); err != nil {
+		exitErr = exitErr.Append(err)
+		goto shutdown
 	}
 
 	// account for any modules added in PreStart
-	runModules = sortModules(c.modules)
-	for _, rm := range runModules {
+	if runModules, err = c.sorted(); err != nil {
+		exitErr = exitErr.Append(err)
+		goto shutdown
+	}
+	if err := c.each(runModules, false, func(rm *moduleReference) error {
 		ts := time.Now()
 		c.logger.Debug("Starting module", "module", rm.name)
-		if err := rm.implementation.Start(ctx); err != nil {
-			exitErr = exitErr.Append(fmt.Errorf("failed to start module %q: %w", rm.name, err))
-			goto shutdown
+		if err := c.call(ctx, rm, PhaseStart, rm.implementation.Start); err != nil {
+			// the abandoned Start may still complete, so the module is stopped like a started one
+			rm.setStarted(errors.Is(err, ErrTimeout))
+			return fmt.Errorf("failed to start module %q: %w", rm.name, err)
 		}
-		rm.started = true
+		rm.setStarted(true)
 		c.logger.Debug("Started module", "module", rm.name, "duration", time.Since(ts))
+
+		return nil
+	}); err != nil {
+		exitErr = exitErr.Append(err)
+		goto shutdown
 	}
 
-	for _, rm := range runModules {
-		if poststarter, ok := rm.implementation.(PostStarter); ok {
-			ts := time.Now()
-			c.logger.Debug("PostStarting module", "module", rm.name)
-			if err := poststarter.PostStart(ctx); err != nil {
-				exitErr = exitErr.Append(fmt.Errorf("failed to poststart module %q: %w", rm.name, err))
-				goto shutdown
-			}
-			c.logger.Debug("PostStarted module", "module", rm.name, "duration", time.Since(ts))
+	if err := c.each(runModules, false, func(rm *moduleReference) error {
+		poststarter, ok := rm.implementation.(PostStarter)
+		if !ok {
+			return nil
 		}
+
+		ts := time.Now()
+		c.logger.Debug("PostStarting module", "module", rm.name)
+		if err := c.call(ctx, rm, PhasePostStart, poststarter.PostStart); err != nil {
+			return fmt.Errorf("failed to poststart module %q: %w", rm.name, err)
+		}
+		c.logger.Debug("PostStarted module", "module", rm.name, "duration", time.Since(ts))
+
+		return nil
+	}); err != nil {
+		exitErr = exitErr.Append(err)
+		goto shutdown
 	}
 
 	c.logger.Debug("Module controller intializations completed", "duration", time.Since(sts))
+	c.ready.Store(true)
 
-	if ctx.Done() != nil {
-		<-ctx.Done()
+	if err := c.wait(ctx, runModules); err != nil {
+		exitErr = exitErr.Append(err)
 	}
 
 shutdown:
+	c.ready.Store(false)
 	sts = time.Now()
 	c.logger.Debug("Module controller teardown starting")
 
-	// reverse them
-	for i, j := 0, len(runModules)-1; i < j; i, j = i+1, j-1 {
-		runModules[i], runModules[j] = runModules[j], runModules[i]
-	}
+	// modules are stopped after the context is cancelled, so stop them with one that isn't
+	stopCtx := context.WithoutCancel(ctx)
 
-	for _, rm := range runModules {
+	exitErr = exitErr.Append(c.each(runModules, true, func(rm *moduleReference) error {
 		// only call stop on started modules
-		if !rm.started {
-			continue
+		if !rm.isStarted() {
+			return nil
 		}
 
 		ts := time.Now()
 		c.logger.Debug("Stopping module", "module", rm.name)
-		rm.started = false
-		if err := rm.implementation.Stop(ctx); err != nil {
-			exitErr = exitErr.Append(fmt.Errorf("failed to stop module %q: %w", rm.name, err))
+		rm.setStarted(false)
+		if err := c.call(stopCtx, rm, PhaseStop, rm.implementation.Stop); err != nil {
+			return fmt.Errorf("failed to stop module %q: %w", rm.name, err)
 		}
 		c.logger.Debug("Stopped module", "module", rm.name, "duration", time.Since(ts))
-	}
+
+		return nil
+	}))
 
 	if ctx.Err() != context.Canceled {
 		exitErr = exitErr.Append(ctx.Err())
@@ -227,7 +334,228 @@ shutdown:
 	return exitErr.Err()
 }
 
-func sortModules(modules map[string]*moduleReference) []*moduleReference {
+// each calls fn for the sorted modules, in reverse when reverse is true.
+//
+// Serially, the first error ends a forward run while a reverse run calls fn on every module. Concurrently, fn runs as soon as
+// every module it depends on (or that depends on it in reverse) has completed, a forward run skips the modules that depend
+// on a failed module, and all errors are collected.
+func (c *Controller) each(modules []*moduleReference, reverse bool, fn func(rm *moduleReference) error) error {
+	if !c.Concurrent {
+		exitErr := new(Error)
+		for i := range modules {
+			rm := modules[i]
+			if reverse {
+				rm = modules[len(modules)-1-i]
+			}
+
+			if err := fn(rm); err != nil {
+				if !reverse {
+					return err
+				}
+
+				exitErr = exitErr.Append(err)
+			}
+		}
+
+		return exitErr.Err()
+	}
+
+	done := make(map[string]chan struct{}, len(modules))
+	for _, rm := range modules {
+		done[rm.name] = make(chan struct{})
+	}
+
+	// the modules that must complete before each module runs
+	waits := make(map[string][]string, len(modules))
+	for _, rm := range modules {
+		for _, dep := range dependencies(rm) {
+			if _, found := done[dep]; !found {
+				continue
+			}
+
+			if reverse {
+				waits[dep] = append(waits[dep], rm.name)
+			} else {
+				waits[rm.name] = append(waits[rm.name], dep)
+			}
+		}
+	}
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	failed := make(map[string]bool)
+	exitErr := new(Error)
+
+	for _, rm := range modules {
+		wg.Add(1)
+		go func(rm *moduleReference) {
+			defer wg.Done()
+			defer close(done[rm.name])
+
+			skip := false
+			for _, name := range waits[rm.name] {
+				<-done[name]
+
+				lock.Lock()
+				skip = skip || failed[name]
+				lock.Unlock()
+			}
+
+			if skip && !reverse {
+				lock.Lock()
+				failed[rm.name] = true
+				lock.Unlock()
+				return
+			}
+
+			if err := fn(rm); err != nil {
+				lock.Lock()
+				failed[rm.name] = true
+				exitErr = exitErr.Append(err)
+				lock.Unlock()
+			}
+		}(rm)
+	}
+
+	wg.Wait()
+
+	return exitErr.Err()
+}
+
+// call fn within the timeout of the phase for the module, when it is exceeded the context given to fn is cancelled and
+// ErrTimeout is returned without waiting for fn
+func (c *Controller) call(ctx context.Context, rm *moduleReference, phase Phase, fn func(ctx context.Context) error) error {
+	timeout := c.Timeouts[phase]
+	if timeouter, ok := rm.implementation.(Timeouter); ok {
+		if t := timeouter.Timeout(phase); t > 0 {
+			timeout = t
+		}
+	}
+
+	if phase == PhaseStop {
+		rm.transition(StateStopping, nil)
+	}
+
+	events := phaseEvents[phase]
+	c.emit(Event{Type: events[0], Module: rm.name, Phase: phase})
+
+	ts := time.Now()
+	err := callTimeout(ctx, timeout, boundedPhases[phase], fn)
+	duration := time.Since(ts)
+	rm.record(phase, duration)
+
+	if err != nil {
+		rm.transition(StateFailed, err)
+		c.emit(Event{Type: EventFailed, Module: rm.name, Phase: phase, Duration: duration, Err: err})
+	} else {
+		if state, ok := phaseStates[phase]; ok {
+			rm.transition(state, nil)
+		}
+		c.emit(Event{Type: events[1], Module: rm.name, Phase: phase, Duration: duration})
+	}
+
+	return err
+}
+
+// boundedPhases are the phases whose context is cancelled when the module returns, modules may tie background work to
+// the context of the other phases so it is only cancelled when they exceed their timeout
+var boundedPhases = map[Phase]bool{
+	PhasePreStart: true,
+	PhaseStop:     true,
+}
+
+// callTimeout calls fn with a context cancelled after the timeout, returning ErrTimeout without waiting for fn when it is
+// exceeded, a timeout of zero or less calls fn without one. When bounded the context is also cancelled once fn returns.
+func callTimeout(ctx context.Context, timeout time.Duration, bounded bool, fn func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		if bounded {
+			cancel()
+		}
+	}()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- fn(ctx) }()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-timer.C:
+		cancel()
+		return fmt.Errorf("%w after %s", ErrTimeout, timeout)
+	}
+}
+
+// sorted returns the modules ordered by sortModules
+func (c *Controller) sorted() ([]*moduleReference, error) {
+	c.once.Do(c.init)
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return sortModules(c.modules)
+}
+
+// sortModules orders the modules so every module follows its dependencies, modules without a dependency relationship retain the order they were added
+func sortModules(modules map[string]*moduleReference) ([]*moduleReference, error) {
+	ordered := orderModules(modules)
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int, len(ordered))
+	mods := make([]*moduleReference, 0, len(ordered))
+
+	var visit func(mr *moduleReference, path []string) error
+	visit = func(mr *moduleReference, path []string) error {
+		switch state[mr.name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(append(path, mr.name), " -> "))
+		}
+
+		state[mr.name] = visiting
+		path = append(path, mr.name)
+
+		for _, dep := range dependencies(mr) {
+			dr, found := modules[dep]
+			if !found {
+				return fmt.Errorf("%w: module %q depends on %q", ErrMissingDependency, mr.name, dep)
+			}
+
+			if err := visit(dr, path); err != nil {
+				return err
+			}
+		}
+
+		state[mr.name] = visited
+		mods = append(mods, mr)
+
+		return nil
+	}
+
+	for _, mr := range ordered {
+		if err := visit(mr, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return mods, nil
+}
+
+// orderModules orders the modules in the order they were added
+func orderModules(modules map[string]*moduleReference) []*moduleReference {
 	current := 0
 	mods := make([]*moduleReference, len(modules))
 
@@ -243,6 +571,16 @@ func sortModules(modules map[string]*moduleReference) []*moduleReference {
 	return mods
 }
 
+// dependencies returns the module names the referenced module depends on
+func dependencies(mr *moduleReference) []string {
+	dependent, ok := mr.implementation.(Dependent)
+	if !ok {
+		return nil
+	}
+
+	return dependent.Dependencies()
+}
+
 // need this in order to deal with the private type returns of nil
 //
 // example being:
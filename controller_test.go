@@ -0,0 +1,309 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recorder struct {
+	sync.Mutex
+	calls []string
+}
+
+func (r *recorder) record(call string) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.calls = append(r.calls, call)
+}
+
+type testModule struct {
+	name     string
+	deps     []string
+	recorder *recorder
+}
+
+func (m *testModule) Start(ctx context.Context) error {
+	m.recorder.record("start " + m.name)
+	return nil
+}
+
+func (m *testModule) Stop(ctx context.Context) error {
+	m.recorder.record("stop " + m.name)
+	return nil
+}
+
+func (m *testModule) Dependencies() []string {
+	return m.deps
+}
+
+func TestControllerDependencies(t *testing.T) {
+	rec := &recorder{}
+	c := &Controller{}
+	c.Add("http", &testModule{name: "http", deps: []string{"db", "cache"}, recorder: rec})
+	c.Add("cache", &testModule{name: "cache", deps: []string{"db"}, recorder: rec})
+	c.Add("db", &testModule{name: "db", recorder: rec})
+	c.Add("metrics", &testModule{name: "metrics", recorder: rec})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := c.Run(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"start db", "start cache", "start http", "start metrics",
+		"stop metrics", "stop http", "stop cache", "stop db",
+	}
+
+	if !reflect.DeepEqual(rec.calls, expected) {
+		t.Errorf("unexpected lifecycle order:\n expected %v\n got      %v", expected, rec.calls)
+	}
+}
+
+func TestControllerDependencyErrors(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Modules map[string][]string
+		Err     error
+	}{
+		{
+			Name:    "missing",
+			Modules: map[string][]string{"http": {"db"}},
+			Err:     ErrMissingDependency,
+		},
+		{
+			Name:    "cycle",
+			Modules: map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}},
+			Err:     ErrDependencyCycle,
+		},
+		{
+			Name:    "self",
+			Modules: map[string][]string{"a": {"a"}},
+			Err:     ErrDependencyCycle,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			rec := &recorder{}
+			c := &Controller{}
+			for name, deps := range test.Modules {
+				c.Add(name, &testModule{name: name, deps: deps, recorder: rec})
+			}
+
+			if err := c.Validate(); !errors.Is(err, test.Err) {
+				t.Errorf("unexpected validation error: expected %v; got %v", test.Err, err)
+			}
+
+			if err := c.Run(context.Background()); !errors.Is(err, test.Err) {
+				t.Errorf("unexpected run error: expected %v; got %v", test.Err, err)
+			}
+
+			if len(rec.calls) != 0 {
+				t.Errorf("modules should not have run: %v", rec.calls)
+			}
+		})
+	}
+}
+
+type funcModule struct {
+	start func(ctx context.Context) error
+	stop  func(ctx context.Context) error
+	deps  []string
+}
+
+func (m *funcModule) Start(ctx context.Context) error {
+	if m.start == nil {
+		return nil
+	}
+
+	return m.start(ctx)
+}
+
+func (m *funcModule) Stop(ctx context.Context) error {
+	if m.stop == nil {
+		return nil
+	}
+
+	return m.stop(ctx)
+}
+
+func (m *funcModule) Dependencies() []string {
+	return m.deps
+}
+
+func TestControllerConcurrent(t *testing.T) {
+	// both modules must be starting at the same time for either to complete
+	var barrier sync.WaitGroup
+	barrier.Add(2)
+	wait := func(ctx context.Context) error {
+		barrier.Done()
+		barrier.Wait()
+		return nil
+	}
+
+	rec := &recorder{}
+	c := &Controller{Concurrent: true}
+	c.Add("a", &funcModule{start: wait})
+	c.Add("b", &funcModule{start: wait})
+	c.Add("c", &testModule{name: "c", deps: []string{"a", "b"}, recorder: rec})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan error)
+	go func() { done <- c.Run(ctx) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("independent modules did not start concurrently")
+	}
+
+	if expected := []string{"start c", "stop c"}; !reflect.DeepEqual(rec.calls, expected) {
+		t.Errorf("unexpected lifecycle order: expected %v; got %v", expected, rec.calls)
+	}
+}
+
+func TestControllerConcurrentErrors(t *testing.T) {
+	errA := errors.New("a failed")
+	errB := errors.New("b failed")
+
+	rec := &recorder{}
+	c := &Controller{Concurrent: true}
+	c.Add("a", &funcModule{start: func(context.Context) error { return errA }})
+	c.Add("b", &funcModule{start: func(context.Context) error { return errB }})
+	c.Add("c", &testModule{name: "c", deps: []string{"a"}, recorder: rec})
+	c.Add("d", &testModule{name: "d", recorder: rec})
+
+	err := c.Run(context.Background())
+
+	var exitErr Error
+	if !errors.As(err, &exitErr) || len(exitErr.Errors) != 2 {
+		t.Fatalf("expected both start errors; got %v", err)
+	}
+
+	if !errors.Is(exitErr.Errors[0], errA) && !errors.Is(exitErr.Errors[1], errA) {
+		t.Errorf("missing error %v: %v", errA, err)
+	}
+	if !errors.Is(exitErr.Errors[0], errB) && !errors.Is(exitErr.Errors[1], errB) {
+		t.Errorf("missing error %v: %v", errB, err)
+	}
+
+	// c depends on the failed module so is never started, d is independent so is started and stopped
+	if expected := []string{"start d", "stop d"}; !reflect.DeepEqual(rec.calls, expected) {
+		t.Errorf("unexpected lifecycle: expected %v; got %v", expected, rec.calls)
+	}
+}
+
+type timeoutModule struct {
+	funcModule
+	timeouts map[Phase]time.Duration
+}
+
+func (m *timeoutModule) Timeout(phase Phase) time.Duration {
+	return m.timeouts[phase]
+}
+
+func TestControllerTimeouts(t *testing.T) {
+	cancelled := make(chan struct{})
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	rec := &recorder{}
+	c := &Controller{Timeouts: map[Phase]time.Duration{PhaseStop: time.Hour}}
+	c.Add("first", &testModule{name: "first", recorder: rec})
+	c.Add("hung", &timeoutModule{
+		funcModule: funcModule{stop: func(ctx context.Context) error {
+			<-ctx.Done()
+			close(cancelled)
+			<-release
+			return nil
+		}},
+		timeouts: map[Phase]time.Duration{PhaseStop: 50 * time.Millisecond},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := c.Run(ctx)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected timeout error; got %v", err)
+	}
+
+	if expected := []string{"start first", "stop first"}; !reflect.DeepEqual(rec.calls, expected) {
+		t.Errorf("unexpected lifecycle: expected %v; got %v", expected, rec.calls)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Error("context of the hung module was not cancelled")
+	}
+}
+
+func TestControllerLateStart(t *testing.T) {
+	stopped := make(chan struct{})
+
+	c := &Controller{Timeouts: map[Phase]time.Duration{PhaseStart: 10 * time.Millisecond}}
+	c.Add("late", &funcModule{
+		start: func(context.Context) error {
+			// completes after the timeout, ignoring the context
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		},
+		stop: func(context.Context) error {
+			close(stopped)
+			return nil
+		},
+	})
+
+	if err := c.Run(context.Background()); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected timeout error; got %v", err)
+	}
+
+	select {
+	case <-stopped:
+	default:
+		t.Error("module whose start timed out was not stopped")
+	}
+}
+
+func TestControllerStartTimeout(t *testing.T) {
+	startCtx := make(chan context.Context, 1)
+
+	c := &Controller{Timeouts: map[Phase]time.Duration{PhaseStart: time.Hour}}
+	c.Add("background", &funcModule{start: func(ctx context.Context) error {
+		startCtx <- ctx
+		return nil
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- c.Run(ctx) }()
+
+	// the context given to Start lives on after Start returns, until the controller shuts down
+	started := <-startCtx
+	time.Sleep(10 * time.Millisecond)
+	if err := started.Err(); err != nil {
+		t.Errorf("context of the started module was cancelled: %v", err)
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+
+	if started.Err() == nil {
+		t.Error("context of the started module was not cancelled on shutdown")
+	}
+}
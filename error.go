@@ -21,18 +21,11 @@ func (e *Error) Append(err error) *Error {
 
 	switch errCast := err.(type) {
 	case *Error:
-		for _, subErr := range errCast.Errors {
-			switch subErr := subErr.(type) {
-			case *Error:
-				if subErr != nil {
-					e.Errors = append(e.Errors, subErr.Errors...)
-				}
-			default:
-				if subErr != nil {
-					e.Errors = append(e.Errors, subErr)
-				}
-			}
+		if errCast != nil {
+			e.appendErrors(errCast.Errors)
 		}
+	case Error:
+		e.appendErrors(errCast.Errors)
 	default:
 		e.Errors = append(e.Errors, errCast)
 	}
@@ -40,6 +33,24 @@ func (e *Error) Append(err error) *Error {
 	return e
 }
 
+// appendErrors flattens any nested errors into e
+func (e *Error) appendErrors(errs []error) {
+	for _, subErr := range errs {
+		switch subErr := subErr.(type) {
+		case *Error:
+			if subErr != nil {
+				e.Errors = append(e.Errors, subErr.Errors...)
+			}
+		case Error:
+			e.Errors = append(e.Errors, subErr.Errors...)
+		default:
+			if subErr != nil {
+				e.Errors = append(e.Errors, subErr)
+			}
+		}
+	}
+}
+
 func (e Error) Error() string {
 	// not the best formatter, but it works
 	if len(e.Errors) == 0 {
@@ -24,3 +24,14 @@ func TestErrorAppend(t *testing.T) {
 		t.Errorf("unexpected error: expected %q; got %q", err2.Error(), err.Errors[1].Error())
 	}
 }
+
+func TestErrorAppendFlattens(t *testing.T) {
+	var inner *Error
+	inner = inner.Append(errors.New("test 1")).Append(errors.New("test 2"))
+
+	err := new(Error).Append(inner.Err()).Append(inner)
+
+	if len(err.Errors) != 4 {
+		t.Fatalf("errors did not get flattened: %#v", err.Errors)
+	}
+}
@@ -0,0 +1,105 @@
+package application
+
+import (
+	"sync"
+	"time"
+)
+
+// EventType of a lifecycle Event
+type EventType string
+
+// Lifecycle event types
+const (
+	EventInitializing EventType = "initializing"
+	EventInitialized  EventType = "initialized"
+	EventInstalling   EventType = "installing"
+	EventInstalled    EventType = "installed"
+	EventPreStarting  EventType = "prestarting"
+	EventPreStarted   EventType = "prestarted"
+	EventStarting     EventType = "starting"
+	EventStarted      EventType = "started"
+	EventPostStarting EventType = "poststarting"
+	EventPostStarted  EventType = "poststarted"
+	EventStopping     EventType = "stopping"
+	EventStopped      EventType = "stopped"
+	EventFailed       EventType = "failed"
+)
+
+// phaseEvents are the event types emitted before and after each phase completes successfully
+var phaseEvents = map[Phase][2]EventType{
+	PhaseInitialize: {EventInitializing, EventInitialized},
+	PhaseInstall:    {EventInstalling, EventInstalled},
+	PhasePreStart:   {EventPreStarting, EventPreStarted},
+	PhaseStart:      {EventStarting, EventStarted},
+	PhasePostStart:  {EventPostStarting, EventPostStarted},
+	PhaseStop:       {EventStopping, EventStopped},
+}
+
+// Event of a module lifecycle
+type Event struct {
+	Type   EventType
+	Module string
+
+	// Phase the event occurred in, empty for failures of a running Supervised module
+	Phase Phase
+
+	Time time.Time
+
+	// Duration of the phase for events emitted when a phase completes or fails
+	Duration time.Duration
+
+	// Err the module failed with for EventFailed
+	Err error
+}
+
+// subscribers to the Controller events
+type subscribers struct {
+	sync.Mutex
+	next     int
+	channels map[int]chan Event
+}
+
+// Subscribe to the lifecycle events of the Controller, which are delivered on the returned channel until the returned
+// func is called to unsubscribe. When the channel buffer is full, events are dropped rather than blocking the lifecycle.
+func (c *Controller) Subscribe(buffer int) (<-chan Event, func()) {
+	c.events.Lock()
+	defer c.events.Unlock()
+
+	if c.events.channels == nil {
+		c.events.channels = make(map[int]chan Event)
+	}
+
+	id := c.events.next
+	c.events.next++
+
+	ch := make(chan Event, buffer)
+	c.events.channels[id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			c.events.Lock()
+			defer c.events.Unlock()
+
+			delete(c.events.channels, id)
+			close(ch)
+		})
+	}
+}
+
+// emit the event to every subscriber without blocking
+func (c *Controller) emit(event Event) {
+	c.events.Lock()
+	defer c.events.Unlock()
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	for _, ch := range c.events.channels {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestControllerEvents(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	c := &Controller{}
+	c.Add("ok", &funcModule{})
+	c.Add("failing", &funcModule{stop: func(context.Context) error { return errFailed }})
+
+	events, unsubscribe := c.Subscribe(100)
+	defer unsubscribe()
+
+	// never read, so it must not block the lifecycle
+	_, unsubscribeBlocked := c.Subscribe(0)
+	defer unsubscribeBlocked()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := c.Run(ctx); !errors.Is(err, errFailed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	unsubscribe()
+
+	var got []string
+	var failure Event
+	for event := range events {
+		got = append(got, event.Module+" "+string(event.Type))
+		if event.Type == EventFailed {
+			failure = event
+		}
+	}
+
+	expected := []string{
+		"ok starting", "ok started",
+		"failing starting", "failing started",
+		"failing stopping", "failing failed",
+		"ok stopping", "ok stopped",
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected events:\n expected %v\n got      %v", expected, got)
+	}
+
+	if failure.Phase != PhaseStop || !errors.Is(failure.Err, errFailed) || failure.Time.IsZero() {
+		t.Errorf("unexpected failure event: %+v", failure)
+	}
+}
@@ -0,0 +1,124 @@
+package application
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/portcullis/application/confighcl"
+)
+
+// configFlag is the flag.Value of a generated flag, recording every value in the order it is set
+type configFlag struct {
+	name    string
+	field   confighcl.Field
+	boolean bool
+	set     func(confighcl.FieldValue)
+}
+
+func (f *configFlag) String() string { return "" }
+
+func (f *configFlag) Set(s string) error {
+	f.set(confighcl.FieldValue{Field: f.field, Name: "-" + f.name, Src: s})
+	return nil
+}
+
+func (f *configFlag) IsBoolFlag() bool { return f.boolean }
+
+// flagGroup are the flags of a module
+type flagGroup struct {
+	module string
+	flags  []*configFlag
+}
+
+// ParseFlags generates a flag for every field of the Configurable modules of the application in the context, named by
+// the module name and the path of config tag names such as -http.tls.cert, and parses the arguments into overrides that
+// are applied after the configuration files and environment variables.
+//
+// Errors and the usage for -help, which returns flag.ErrHelp, are written to the output of the application.
+func (c *Configuration) ParseFlags(ctx context.Context, args []string) error {
+	app := FromContext(ctx)
+	if app == nil {
+		return nil
+	}
+
+	c.flags = make(map[string][]confighcl.FieldValue)
+
+	fs := flag.NewFlagSet(app.Name, flag.ContinueOnError)
+	fs.SetOutput(app.output)
+
+	var groups []flagGroup
+	app.Controller.Range(func(name string, m Module) bool {
+		cfgr, ok := m.(Configurable)
+		if !ok {
+			return true
+		}
+
+		v, err := cfgr.Config()
+		if err != nil || isNil(v) {
+			return true
+		}
+
+		group := flagGroup{module: name}
+		for _, field := range confighcl.Fields(v) {
+			module := name
+			f := &configFlag{
+				name:    strings.Join(append([]string{name}, field.Path...), "."),
+				field:   field,
+				boolean: field.Type == "bool",
+				set:     func(fv confighcl.FieldValue) { c.flags[module] = append(c.flags[module], fv) },
+			}
+
+			fs.Var(f, f.name, c.flagUsage(name, field))
+			group.flags = append(group.flags, f)
+		}
+
+		if len(group.flags) > 0 {
+			groups = append(groups, group)
+		}
+
+		return true
+	})
+
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage of %s:\n", app.Name)
+
+		for _, group := range groups {
+			fmt.Fprintf(out, "\n%s:\n", group.module)
+			for _, f := range group.flags {
+				line := "  -" + f.name
+				if !f.boolean {
+					line += " " + f.field.Type
+				}
+
+				fmt.Fprintf(out, "%s\n    \t%s\n", line, fs.Lookup(f.name).Usage)
+			}
+		}
+
+		fmt.Fprintf(out, "\ncommands:\n  %s\n    \tWrites a sample configuration file to standard output\n", SampleConfigCommand)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	c.args = fs.Args()
+
+	return nil
+}
+
+// flagUsage describes the flag of the module field
+func (c *Configuration) flagUsage(module string, field confighcl.Field) string {
+	usage := fmt.Sprintf("Overrides %s of the %s module", strings.Join(field.Path, "."), module)
+	if field.Description != "" {
+		usage = field.Description
+	}
+
+	if c.env {
+		usage += fmt.Sprintf(" (environment variable %s)", confighcl.EnvName(append([]string{c.envPrefix, module}, field.Path...)...))
+	}
+
+	return usage
+}
@@ -0,0 +1,97 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlags(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.hcl")
+	if err := os.WriteFile(filename, []byte(`
+listen_addr = ":8080"
+timeout     = "1s"
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("APP_HTTP_LISTEN_ADDR", ":9090")
+	t.Setenv("APP_HTTP_TIMEOUT", "5s")
+
+	m := &envModule{}
+	app := New("test", "1.0.0",
+		WithModule("http", m),
+		WithConfigFile(filename),
+		WithEnvOverrides("APP"),
+		WithFlags("-http.timeout", "10s", "-http.hosts", `["a", "b"]`, "-http.tls.enabled", "-http.tls.cert=cert.pem", "-http.timeout=20s"),
+	)
+	if err := app.Validate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := envConfig{
+		ListenAddr: ":9090",
+		Timeout:    20 * time.Second,
+		Hosts:      []string{"a", "b"},
+		TLS:        &envTLSConfig{Cert: "cert.pem", Enabled: true},
+	}
+	if !reflect.DeepEqual(m.config, expected) {
+		t.Errorf("unexpected configuration: expected %+v; got %+v", expected, m.config)
+	}
+
+	t.Run("help", func(t *testing.T) {
+		var out bytes.Buffer
+		app := New("test", "1.0.0", WithModule("http", &envModule{}), WithModule("other", &reloadModule{}), WithFlags("-help"), WithOutput(&out))
+		if err := app.Validate(context.Background()); !errors.Is(err, flag.ErrHelp) {
+			t.Fatalf("expected help error; got %v", err)
+		}
+
+		usage := out.String()
+		for _, expected := range []string{"Usage of test:", "\nhttp:\n", "  -http.timeout duration\n", "  -http.tls.enabled\n", "\nother:\n", "  -other.hosts list of string\n", "\ncommands:\n  sample-config\n"} {
+			if !strings.Contains(usage, expected) {
+				t.Errorf("usage does not contain %q:\n%s", expected, usage)
+			}
+		}
+
+		if strings.Index(usage, "-other.hosts") < strings.Index(usage, "-http.tls.enabled") {
+			t.Errorf("flags are not grouped by module:\n%s", usage)
+		}
+
+		if err := app.Run(context.Background()); err != nil {
+			t.Errorf("unexpected run error: %v", err)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		app := New("test", "1.0.0", WithModule("http", &envModule{}), WithFlags("-http.timeout", "soon"), WithOutput(&bytes.Buffer{}))
+		if err := app.Validate(context.Background()); err == nil || !strings.Contains(err.Error(), "-http.timeout") {
+			t.Errorf("expected error referencing the flag; got %v", err)
+		}
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		// without a configuration file or a flag set, the modules keep the values they start with
+		m := &validateModule{}
+		app := New("test", "1.0.0", WithModule("server", m), WithFlags([]string{}...))
+		if err := app.Validate(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(m.config, validateConfig{}) {
+			t.Errorf("unexpected configuration: %+v", m.config)
+		}
+	})
+
+	t.Run("undefined", func(t *testing.T) {
+		app := New("test", "1.0.0", WithModule("http", &envModule{}), WithFlags("-http.tiemout", "5s"), WithOutput(&bytes.Buffer{}))
+		if err := app.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "-http.tiemout") {
+			t.Errorf("expected error referencing the flag; got %v", err)
+		}
+	})
+}
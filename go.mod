@@ -3,14 +3,16 @@ module github.com/portcullis/application
 go 1.21
 
 require (
+	github.com/agext/levenshtein v1.2.3
 	github.com/hashicorp/hcl/v2 v2.17.0
+	github.com/pelletier/go-toml/v2 v2.2.2
 	github.com/portcullis/config v0.1.0
 	github.com/zclconf/go-cty v1.13.3
 	github.com/zclconf/go-cty-yaml v1.0.3
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/agext/levenshtein v1.2.3 // indirect
 	github.com/apparentlymart/go-textseg/v13 v13.0.0 // indirect
 	github.com/google/go-cmp v0.5.9 // indirect
 	github.com/mitchellh/go-wordwrap v1.0.1 // indirect
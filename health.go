@@ -0,0 +1,102 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultHealthTimeout is used for health checks when the Controller HealthTimeout is not set
+const DefaultHealthTimeout = 5 * time.Second
+
+// errCheckRunning fails the health of a module whose previous check has not returned yet
+var errCheckRunning = errors.New("previous health check is still running")
+
+// HealthReport of the modules in a Controller
+type HealthReport struct {
+	// Live is true when every module health check passed
+	Live bool `json:"live"`
+
+	// Ready is true when the Controller is Ready and the report is Live
+	Ready bool `json:"ready"`
+
+	// Modules contains the health of each module implementing HealthChecker in the order they are run
+	Modules []ModuleHealth `json:"modules"`
+}
+
+// ModuleHealth is the result of a module health check
+type ModuleHealth struct {
+	Name     string        `json:"name"`
+	Healthy  bool          `json:"healthy"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Ready returns true once every module has started, it returns false again when the modules begin stopping
+func (c *Controller) Ready() bool {
+	return c.ready.Load()
+}
+
+// Health runs the check of every module implementing HealthChecker in parallel, each limited by the HealthTimeout
+func (c *Controller) Health(ctx context.Context) *HealthReport {
+	modules, err := c.sorted()
+	if err != nil {
+		c.lock.RLock()
+		modules = orderModules(c.modules)
+		c.lock.RUnlock()
+	}
+
+	timeout := c.HealthTimeout
+	if timeout == 0 {
+		timeout = DefaultHealthTimeout
+	}
+
+	var checked []*moduleReference
+	report := &HealthReport{Live: true}
+	for _, rm := range modules {
+		if _, ok := rm.implementation.(HealthChecker); ok {
+			checked = append(checked, rm)
+			report.Modules = append(report.Modules, ModuleHealth{Name: rm.name})
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, rm := range checked {
+		wg.Add(1)
+		go func(rm *moduleReference, health *ModuleHealth) {
+			defer wg.Done()
+
+			ts := time.Now()
+			err := rm.checkHealth(ctx, timeout)
+			health.Duration = time.Since(ts)
+			health.Healthy = err == nil
+			if err != nil {
+				health.Error = err.Error()
+			}
+		}(rm, &report.Modules[i])
+	}
+
+	wg.Wait()
+
+	for _, health := range report.Modules {
+		report.Live = report.Live && health.Healthy
+	}
+
+	report.Ready = report.Live && c.Ready()
+
+	return report
+}
+
+// checkHealth runs the check of the module within the timeout, a check that ignores the cancellation of its context is
+// abandoned and no other check of the module is run until it returns
+func (rm *moduleReference) checkHealth(ctx context.Context, timeout time.Duration) error {
+	if !rm.checking.CompareAndSwap(false, true) {
+		return errCheckRunning
+	}
+
+	return callTimeout(ctx, timeout, true, func(ctx context.Context) error {
+		defer rm.checking.Store(false)
+		return rm.implementation.(HealthChecker).CheckHealth(ctx)
+	})
+}
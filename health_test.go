@@ -0,0 +1,115 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type healthModule struct {
+	funcModule
+	check func(ctx context.Context) error
+}
+
+func (m *healthModule) CheckHealth(ctx context.Context) error {
+	return m.check(ctx)
+}
+
+func TestControllerHealth(t *testing.T) {
+	started := make(chan struct{})
+
+	c := &Controller{HealthTimeout: 50 * time.Millisecond}
+	c.Add("healthy", &healthModule{check: func(context.Context) error { return nil }})
+	c.Add("unhealthy", &healthModule{check: func(context.Context) error { return errors.New("unhealthy") }})
+	c.Add("hung", &healthModule{check: func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }})
+	c.Add("plain", &funcModule{})
+	c.Add("started", &healthModule{
+		funcModule: funcModule{start: func(context.Context) error { close(started); return nil }},
+		check:      func(context.Context) error { return nil },
+	})
+
+	report := c.Health(context.Background())
+	if report.Live || report.Ready {
+		t.Errorf("report should not be live or ready: %+v", report)
+	}
+
+	expected := []struct {
+		Name    string
+		Healthy bool
+	}{{"healthy", true}, {"unhealthy", false}, {"hung", false}, {"started", true}}
+
+	if len(report.Modules) != len(expected) {
+		t.Fatalf("unexpected modules: %+v", report.Modules)
+	}
+
+	for i, e := range expected {
+		if report.Modules[i].Name != e.Name || report.Modules[i].Healthy != e.Healthy {
+			t.Errorf("unexpected module health: expected %+v; got %+v", e, report.Modules[i])
+		}
+	}
+
+	c.Remove("unhealthy")
+	c.Remove("hung")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- c.Run(ctx) }()
+
+	<-started
+	for i := 0; !c.Ready() && i < 100; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if report := c.Health(ctx); !report.Live || !report.Ready {
+		t.Errorf("report should be live and ready: %+v", report)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if c.Ready() {
+		t.Error("controller should not be ready after stopping")
+	}
+}
+
+func TestControllerHealthRunning(t *testing.T) {
+	release := make(chan struct{})
+	var once sync.Once
+	t.Cleanup(func() { once.Do(func() { close(release) }) })
+
+	checks := make(chan struct{}, 3)
+	c := &Controller{HealthTimeout: 10 * time.Millisecond}
+	c.Add("stuck", &healthModule{check: func(context.Context) error {
+		checks <- struct{}{}
+		<-release
+		return nil
+	}})
+
+	// the first check ignores the cancellation of its context, so the next one is skipped until it returns
+	for i := 0; i < 2; i++ {
+		if report := c.Health(context.Background()); report.Live {
+			t.Errorf("report should not be live: %+v", report)
+		}
+	}
+
+	if report := c.Health(context.Background()); report.Modules[0].Error != errCheckRunning.Error() {
+		t.Errorf("expected the check to be skipped; got %+v", report.Modules[0])
+	}
+
+	once.Do(func() { close(release) })
+	for i := 0; c.Health(context.Background()).Modules[0].Error == errCheckRunning.Error() && i < 100; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if report := c.Health(context.Background()); !report.Live {
+		t.Errorf("report should be live once the check returned: %+v", report)
+	}
+
+	if n := len(checks); n != 3 {
+		t.Errorf("expected 3 checks; got %d", n)
+	}
+}
@@ -1,6 +1,22 @@
 package application
 
-import "context"
+import (
+	"context"
+	"time"
+)
+
+// Phase of the module lifecycle
+type Phase string
+
+// Lifecycle phases in the order the Controller runs them
+const (
+	PhaseInitialize Phase = "initialize"
+	PhaseInstall    Phase = "install"
+	PhasePreStart   Phase = "prestart"
+	PhaseStart      Phase = "start"
+	PhasePostStart  Phase = "poststart"
+	PhaseStop       Phase = "stop"
+)
 
 // Module represents an interface into a start stop module
 type Module interface {
@@ -23,6 +39,37 @@ type PostStarter interface {
 	PostStart(ctx context.Context) error
 }
 
+// Dependent can be optionally implemented by any module to declare the names of the modules it depends on.
+//
+// The Controller runs dependencies before the module and stops them after it.
+type Dependent interface {
+	Dependencies() []string
+}
+
+// Timeouter can be optionally implemented by any module to override the Controller timeout of a lifecycle phase.
+//
+// Returning zero will use the Controller timeout for the phase.
+type Timeouter interface {
+	Timeout(phase Phase) time.Duration
+}
+
+// Supervised can be optionally implemented by any module to be restarted by the Controller when it fails after Start.
+//
+// A failure is escalated to the Controller, stopping all modules, once the RestartPolicy is exhausted.
+type Supervised interface {
+	// Failed returns a channel that receives an error when the module fails, it is called again after every restart.
+	// Receiving a nil error ends supervision of the module.
+	Failed() <-chan error
+
+	// RestartPolicy used when the module fails
+	RestartPolicy() RestartPolicy
+}
+
+// HealthChecker can be optionally implemented by any module to report its health, returning an error when unhealthy
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
 // Configurable can be optionally implemented by any module to accept user configuration.
 type Configurable interface {
 	// Config should return a pointer to an allocated configuration
@@ -44,6 +91,21 @@ type ConfigurableNotify interface {
 	Configurable
 
 	// ConfigSet is called with the value of the configuration after
-	// decoding is complete successfully.
+	// decoding is complete successfully. Modules are notified in the
+	// dependency order of the Controller, except that a module whose
+	// configuration refers to another module as module.name is
+	// notified after it.
 	ConfigSet(interface{}) error
 }
+
+// Reloader is an optional interface that can be implemented by a
+// Configurable module to receive the configuration when the application
+// is reloaded.
+type Reloader interface {
+	Configurable
+
+	// Reload is called with a newly allocated configuration after the
+	// configuration file was decoded successfully, the value returned
+	// from Config is left untouched.
+	Reload(ctx context.Context, config interface{}) error
+}
@@ -1,6 +1,14 @@
 package application
 
-import "log/slog"
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/portcullis/application/confighcl/funcs"
+)
 
 // Option for an Application
 type Option func(app *Application)
@@ -12,11 +20,153 @@ func WithModule(name string, m Module) Option {
 	}
 }
 
-// WithConfigFile adds hcl parsing capability to the application and loads the provided filename
-func WithConfigFile(filename string) Option {
+// WithConcurrency will run the lifecycle of modules without a dependency relationship in parallel
+func WithConcurrency() Option {
+	return func(a *Application) {
+		a.Controller.Concurrent = true
+	}
+}
+
+// WithTimeout will limit how long each module may take in the specified lifecycle phase
+func WithTimeout(phase Phase, timeout time.Duration) Option {
+	return func(a *Application) {
+		if a.Controller.Timeouts == nil {
+			a.Controller.Timeouts = make(map[Phase]time.Duration)
+		}
+
+		a.Controller.Timeouts[phase] = timeout
+	}
+}
+
+// WithConfigFile adds hcl parsing capability to the application and loads the provided files and directories in order.
+//
+// The format of each file is chosen by its extension: .hcl, .json, .yaml, .yml or .toml. Directories load all of their
+// configuration files sorted by name. The files are merged, so later files can add blocks
+// but defining the same attribute more than once is an error.
+func WithConfigFile(paths ...string) Option {
+	return func(a *Application) {
+		if a.configuration == nil {
+			a.configuration = &Configuration{}
+		}
+
+		a.configFiles = append(a.configFiles, paths...)
+	}
+}
+
+// WithEnvOverrides overrides the configuration of each Configurable module with environment variables after the
+// configuration files are decoded, and before ConfigSet is called.
+//
+// The variables are named by the prefix, module name and the path of config tag names, so the listen_addr attribute of
+// the http module with the prefix APP is set by APP_HTTP_LISTEN_ADDR and its nested tls block's cert attribute by
+// APP_HTTP_TLS_CERT. Application.EnvVars lists every recognized variable.
+func WithEnvOverrides(prefix string) Option {
+	return func(a *Application) {
+		if a.configuration == nil {
+			a.configuration = &Configuration{}
+		}
+
+		a.configuration.env = true
+		a.configuration.envPrefix = prefix
+	}
+}
+
+// WithFlags generates command line flags for the configuration of each Configurable module and parses the arguments, or
+// os.Args[1:] when none are provided, before the configuration is loaded.
+//
+// The flags are named by the module name and the path of config tag names, so the listen_addr attribute of the http
+// module is set by -http.listen_addr and its nested tls block's cert attribute by -http.tls.cert. Flags take precedence
+// over the configuration files and environment variables, and -help prints the flags grouped by module.
+func WithFlags(args ...string) Option {
+	return func(a *Application) {
+		if a.configuration == nil {
+			a.configuration = &Configuration{}
+		}
+
+		a.flags = true
+		a.args = args
+	}
+}
+
+// WithCommands enables the built-in commands such as SampleConfigCommand, run when they are the first of the arguments,
+// or os.Args[1:] when none are provided, instead of the application. WithFlags enables them as well.
+func WithCommands(args ...string) Option {
+	return func(a *Application) {
+		if a.configuration == nil {
+			a.configuration = &Configuration{}
+		}
+
+		a.commands = true
+		if args != nil {
+			a.args = args
+		}
+	}
+}
+
+// WithModuleBlocks scopes the configuration of each Configurable module to a block named by the module, so modules can
+// declare the same attributes without colliding. Without it the attributes and blocks of every module are set at the
+// top level of the configuration files.
+//
+// The block of the http module is written as http {} or module "http" {}. Several instances of a module can be added
+// with names such as http.public and http.admin, configured by http "public" {} and http "admin" {} blocks. A module
+// without a block is decoded from an empty body, so its defaults apply.
+func WithModuleBlocks() Option {
+	return func(a *Application) {
+		if a.configuration == nil {
+			a.configuration = &Configuration{}
+		}
+
+		a.configuration.blocks = true
+	}
+}
+
+// WithUnknownKeyWarnings reports the arguments and blocks of the configuration files that no module declares as
+// warnings, which are logged, instead of failing to load the configuration. Either way they are reported with the
+// closest name any module declares, such as: Did you mean "listen_addr" of the http module?
+func WithUnknownKeyWarnings() Option {
+	return func(a *Application) {
+		if a.configuration == nil {
+			a.configuration = &Configuration{}
+		}
+
+		a.configuration.allowUnknown = true
+	}
+}
+
+// WithSecretProvider exposes the provider as a function of the configuration with the given name, so secrets are
+// referred to by name rather than written in the configuration:
+//
+//	application.WithSecretProvider("secret", secret.File("/run/secrets"))
+//
+//	password = secret("db/password")
+//
+// The values of the function are secrets, which can only be decoded into confighcl.Secret attributes so they are
+// never logged or written. It panics when the name is not an identifier or is the name of a built-in function.
+func WithSecretProvider(name string, provider SecretProvider) Option {
+	if !hclsyntax.ValidIdentifier(name) {
+		panic(fmt.Sprintf("secret provider name %q is not an identifier", name))
+	}
+	_, stdlib := funcs.Stdlib()[name]
+	_, filesystem := funcs.Filesystem("")[name]
+	if stdlib || filesystem {
+		panic(fmt.Sprintf("secret provider name %q is the name of a built-in function", name))
+	}
+
+	return func(a *Application) {
+		if a.configuration == nil {
+			a.configuration = &Configuration{}
+		}
+		if a.configuration.secrets == nil {
+			a.configuration.secrets = make(map[string]SecretProvider)
+		}
+
+		a.configuration.secrets[name] = provider
+	}
+}
+
+// WithOutput sets where the application writes command line usage and errors, os.Stderr by default
+func WithOutput(w io.Writer) Option {
 	return func(a *Application) {
-		a.configuration = &Configuration{}
-		a.configFile = filename
+		a.output = w
 	}
 }
 
@@ -0,0 +1,103 @@
+package application
+
+import (
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/portcullis/application/confighcl/funcs"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// fileBody is the body of a configuration file whose expressions resolve the relative paths of the file functions, such
+// as file and templatefile, from the directory of the file
+type fileBody struct {
+	hcl.Body
+	funcs map[string]function.Function
+}
+
+// scopeFile returns the file with a body resolving the relative paths of the file functions from its directory
+func scopeFile(file *hcl.File, filename string) *hcl.File {
+	scoped := *file
+	scoped.Body = &fileBody{Body: file.Body, funcs: funcs.Filesystem(filepath.Dir(filename))}
+
+	return &scoped
+}
+
+func (b *fileBody) Content(schema *hcl.BodySchema) (*hcl.BodyContent, hcl.Diagnostics) {
+	content, diags := b.Body.Content(schema)
+	return b.content(content), diags
+}
+
+func (b *fileBody) PartialContent(schema *hcl.BodySchema) (*hcl.BodyContent, hcl.Body, hcl.Diagnostics) {
+	content, remain, diags := b.Body.PartialContent(schema)
+	if remain != nil {
+		remain = &fileBody{Body: remain, funcs: b.funcs}
+	}
+
+	return b.content(content), remain, diags
+}
+
+func (b *fileBody) JustAttributes() (hcl.Attributes, hcl.Diagnostics) {
+	attrs, diags := b.Body.JustAttributes()
+	return b.attributes(attrs), diags
+}
+
+// content returns the content with the attributes and blocks scoped to the file
+func (b *fileBody) content(content *hcl.BodyContent) *hcl.BodyContent {
+	if content == nil {
+		return nil
+	}
+
+	scoped := *content
+	scoped.Attributes = b.attributes(content.Attributes)
+
+	scoped.Blocks = make(hcl.Blocks, 0, len(content.Blocks))
+	for _, block := range content.Blocks {
+		block := *block
+		block.Body = &fileBody{Body: block.Body, funcs: b.funcs}
+		scoped.Blocks = append(scoped.Blocks, &block)
+	}
+
+	return &scoped
+}
+
+// attributes returns the attributes with their expressions scoped to the file
+func (b *fileBody) attributes(attrs hcl.Attributes) hcl.Attributes {
+	if attrs == nil {
+		return nil
+	}
+
+	scoped := make(hcl.Attributes, len(attrs))
+	for name, attr := range attrs {
+		attr := *attr
+		attr.Expr = &fileExpression{Expression: attr.Expr, funcs: b.funcs}
+		scoped[name] = &attr
+	}
+
+	return scoped
+}
+
+// fileExpression is an expression of a configuration file evaluated with the file functions of the file
+type fileExpression struct {
+	hcl.Expression
+	funcs map[string]function.Function
+}
+
+// Value evaluates the expression in a child of the context providing the file functions, which take precedence over
+// those of the context. Without a context functions cannot be called, so there is none.
+func (e *fileExpression) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
+	if ctx == nil {
+		return e.Expression.Value(nil)
+	}
+
+	child := ctx.NewChild()
+	child.Functions = e.funcs
+
+	return e.Expression.Value(child)
+}
+
+// UnwrapExpression returns the expression of the file, see hcl.UnwrapExpression
+func (e *fileExpression) UnwrapExpression() hcl.Expression {
+	return e.Expression
+}
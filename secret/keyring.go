@@ -0,0 +1,144 @@
+package secret
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// keyringVersion is the version of the format of keyring files
+const keyringVersion = 1
+
+// keyringFile is the format of keyring files, a JSON object of the encrypted secrets by name
+type keyringFile struct {
+	Version int `json:"version"`
+
+	// Secrets are the nonces followed by the sealed values of the secrets, authenticated with their names
+	Secrets map[string][]byte `json:"secrets"`
+}
+
+// KeyringProvider resolves secrets from a local file of secrets encrypted with AES-GCM
+type KeyringProvider struct {
+	path string
+	key  []byte
+}
+
+// Keyring provider resolving secrets from the keyring file at the path, encrypted with the key of 16, 24 or 32 bytes
+// selecting AES-128, AES-192 or AES-256. Secrets are added to the file with Set.
+func Keyring(path string, key []byte) *KeyringProvider {
+	return &KeyringProvider{path: path, key: key}
+}
+
+// Secret decrypts the named secret of the keyring file
+func (p *KeyringProvider) Secret(_ context.Context, name string) (string, error) {
+	aead, err := p.aead()
+	if err != nil {
+		return "", err
+	}
+
+	file, err := p.read()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, ok := file.Secrets[name]
+	if !ok {
+		return "", fmt.Errorf("secret %q does not exist in keyring %s", name, p.path)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("secret %q of keyring %s is malformed", name, p.path)
+	}
+
+	value, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret %q of keyring %s: the key is not the key it was encrypted with", name, p.path)
+	}
+
+	return string(value), nil
+}
+
+// Set encrypts the value of the named secret into the keyring file, creating the file when it does not exist
+func (p *KeyringProvider) Set(name, value string) error {
+	aead, err := p.aead()
+	if err != nil {
+		return err
+	}
+
+	file, err := p.read()
+	if errors.Is(err, fs.ErrNotExist) {
+		file, err = &keyringFile{Version: keyringVersion, Secrets: map[string][]byte{}}, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	file.Secrets[name] = aead.Seal(nonce, nonce, []byte(value), []byte(name))
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// replace the file at once, so it is never partially written
+	tmp, err := os.CreateTemp(filepath.Dir(p.path), "."+filepath.Base(p.path)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to write keyring: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write keyring: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write keyring: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), p.path); err != nil {
+		return fmt.Errorf("failed to write keyring: %w", err)
+	}
+
+	return nil
+}
+
+// aead returns the AES-GCM cipher of the key
+func (p *KeyringProvider) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(p.key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keyring key: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// read decodes the keyring file
+func (p *KeyringProvider) read() (*keyringFile, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyring: %w", err)
+	}
+
+	var file keyringFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to decode keyring %s: %w", p.path, err)
+	}
+	if file.Version != keyringVersion {
+		return nil, fmt.Errorf("unsupported version %d of keyring %s", file.Version, p.path)
+	}
+	if file.Secrets == nil {
+		file.Secrets = map[string][]byte{}
+	}
+
+	return &file, nil
+}
@@ -0,0 +1,86 @@
+// Package secret provides providers resolving the secrets of the configuration of an application.
+//
+// A provider is registered as a function of the configuration:
+//
+//	application.Run("example", "1.0.0",
+//		application.WithModule("db", db.New()),
+//		application.WithSecretProvider("secret", secret.File("/run/secrets")),
+//	)
+//
+// and secrets are referred to by name in attributes of type confighcl.Secret:
+//
+//	db {
+//	  password = secret("db/password")
+//	}
+//
+// The following providers are built in:
+//
+//	File     files in a directory, such as Docker and Kubernetes secret mounts
+//	Env      environment variables named after the secrets
+//	Keyring  a local file of secrets encrypted with AES-GCM
+package secret
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/portcullis/application/confighcl"
+)
+
+// FileProvider resolves secrets from the files in a directory
+type FileProvider struct {
+	dir string
+}
+
+// File provider resolving each secret from the file of its name in the directory, such as /run/secrets/db/password for
+// db/password with the /run/secrets directory. A single trailing newline of the file is not part of the secret.
+func File(dir string) *FileProvider {
+	return &FileProvider{dir: dir}
+}
+
+// Secret reads the file of the named secret, which must be in the directory
+func (p *FileProvider) Secret(_ context.Context, name string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return "", fmt.Errorf("secret name %q is not a path within %s", name, p.dir)
+	}
+
+	filename := filepath.Join(p.dir, filepath.FromSlash(name))
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("secret %q does not exist in %s", name, p.dir)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %q: %w", name, err)
+	}
+
+	value := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(value, "\r"), nil
+}
+
+// EnvProvider resolves secrets from environment variables
+type EnvProvider struct {
+	prefix string
+}
+
+// Env provider resolving each secret from the environment variable named after the prefix and the secret, such as
+// APP_DB_PASSWORD for db/password with the APP prefix, see confighcl.EnvName.
+func Env(prefix string) *EnvProvider {
+	return &EnvProvider{prefix: prefix}
+}
+
+// Secret reads the environment variable of the named secret
+func (p *EnvProvider) Secret(_ context.Context, name string) (string, error) {
+	envName := confighcl.EnvName(p.prefix, name)
+
+	value, ok := os.LookupEnv(envName)
+	if !ok {
+		return "", fmt.Errorf("secret %q is not set by the %s environment variable", name, envName)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,106 @@
+package secret
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/portcullis/application"
+	"github.com/portcullis/application/confighcl"
+)
+
+type dbConfig struct {
+	Password confighcl.Secret `config:"password"`
+	Token    confighcl.Secret `config:"token"`
+	APIKey   confighcl.Secret `config:"api_key"`
+}
+
+type dbModule struct {
+	config dbConfig
+}
+
+func (m *dbModule) Start(context.Context) error { return nil }
+func (m *dbModule) Stop(context.Context) error  { return nil }
+
+func (m *dbModule) Config() (interface{}, error) {
+	return &m.config, nil
+}
+
+func TestProviders(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "db"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "db", "password"), []byte("hunter2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("APP_DB_TOKEN", "t0k3n")
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	keyring := Keyring(filepath.Join(dir, "keyring.json"), key)
+	if err := keyring.Set("api/key", "s3cr3t"); err != nil {
+		t.Fatal(err)
+	}
+	if err := keyring.Set("other", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join(dir, "app.hcl")
+	if err := os.WriteFile(filename, []byte(`
+password = file_secret("db/password")
+token    = env_secret("db/token")
+api_key  = keyring("api/key")
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// file and env are built-in functions, so the providers of files and environment variables are named differently
+	m := &dbModule{}
+	app := application.New("test", "1.0.0",
+		application.WithModule("db", m),
+		application.WithSecretProvider("file_secret", File(dir)),
+		application.WithSecretProvider("env_secret", Env("APP")),
+		application.WithSecretProvider("keyring", keyring),
+		application.WithConfigFile(filename),
+	)
+	if err := app.Validate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(m.config.Password) != "hunter2" || string(m.config.Token) != "t0k3n" || string(m.config.APIKey) != "s3cr3t" {
+		t.Errorf("unexpected secrets: %q, %q, %q", string(m.config.Password), string(m.config.Token), string(m.config.APIKey))
+	}
+
+	ctx := context.Background()
+	for _, test := range []struct {
+		Provider application.SecretProvider
+		Name     string
+		Error    string
+	}{
+		{Provider: File(dir), Name: "db/missing", Error: `secret "db/missing" does not exist in ` + dir},
+		{Provider: File(dir), Name: "../etc/passwd", Error: `secret name "../etc/passwd" is not a path within ` + dir},
+		{Provider: Env("APP"), Name: "db/missing", Error: `secret "db/missing" is not set by the APP_DB_MISSING environment variable`},
+		{Provider: keyring, Name: "missing", Error: `secret "missing" does not exist in keyring`},
+		{Provider: Keyring(keyring.path, []byte("fedcba9876543210fedcba9876543210")), Name: "api/key", Error: "the key is not the key it was encrypted with"},
+		{Provider: Keyring(keyring.path, []byte("short")), Name: "api/key", Error: "invalid keyring key"},
+		{Provider: Keyring(filepath.Join(dir, "missing.json"), key), Name: "api/key", Error: "failed to read keyring"},
+	} {
+		if _, err := test.Provider.Secret(ctx, test.Name); err == nil || !strings.Contains(err.Error(), test.Error) {
+			t.Errorf("unexpected error of %s: expected %q; got %v", test.Name, test.Error, err)
+		}
+	}
+
+	data, err := os.ReadFile(keyring.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "s3cr3t") {
+		t.Errorf("keyring is not encrypted:\n%s", data)
+	}
+	if value, err := keyring.Secret(ctx, "other"); err != nil || value != "value" {
+		t.Errorf("unexpected other secret: %q (%v)", value, err)
+	}
+}
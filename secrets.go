@@ -0,0 +1,39 @@
+package application
+
+import (
+	"context"
+
+	"github.com/portcullis/application/confighcl"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// SecretProvider resolves secrets by name, such as from files mounted by Docker or Kubernetes or from a secret manager.
+// Providers are registered with WithSecretProvider as functions of the configuration, see the secret package for
+// built-in providers.
+type SecretProvider interface {
+	// Secret returns the value of the named secret, or an error when it cannot be resolved
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+// secretFunc returns the configuration function resolving the named secrets of the provider, whose values are marked
+// as secrets so they can only be decoded into confighcl.Secret attributes
+func secretFunc(ctx context.Context, provider SecretProvider) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "name",
+				Type: cty.String,
+			},
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			val, err := provider.Secret(ctx, args[0].AsString())
+			if err != nil {
+				return cty.UnknownVal(cty.String), err
+			}
+
+			return cty.StringVal(val).Mark(confighcl.SecretMark), nil
+		},
+	})
+}
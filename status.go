@@ -0,0 +1,239 @@
+package application
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// maxTransitions kept for each module, older transitions are discarded
+const maxTransitions = 64
+
+// State of a module lifecycle
+type State int
+
+// Module lifecycle states
+const (
+	StateRegistered State = iota
+	StateInitialized
+	StateInstalled
+	StatePreStarted
+	StateRunning
+	StateStopping
+	StateStopped
+	StateFailed
+)
+
+var stateNames = map[State]string{
+	StateRegistered:  "registered",
+	StateInitialized: "initialized",
+	StateInstalled:   "installed",
+	StatePreStarted:  "prestarted",
+	StateRunning:     "running",
+	StateStopping:    "stopping",
+	StateStopped:     "stopped",
+	StateFailed:      "failed",
+}
+
+// phaseStates are the states of a module after completing each phase successfully
+var phaseStates = map[Phase]State{
+	PhaseInitialize: StateInitialized,
+	PhaseInstall:    StateInstalled,
+	PhasePreStart:   StatePreStarted,
+	PhaseStart:      StateRunning,
+	PhaseStop:       StateStopped,
+}
+
+// String returns the name of the state
+func (s State) String() string {
+	if name, found := stateNames[s]; found {
+		return name
+	}
+
+	return "unknown"
+}
+
+// MarshalText encodes the state as its name
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes the state from its name
+func (s *State) UnmarshalText(text []byte) error {
+	for state, name := range stateNames {
+		if name == string(text) {
+			*s = state
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown module state %q", text)
+}
+
+// Transition of a module into a State
+type Transition struct {
+	State State
+	Time  time.Time
+
+	// Err the module failed with when transitioning into StateFailed
+	Err error
+}
+
+type jsonTransition struct {
+	State State     `json:"state"`
+	Time  time.Time `json:"time"`
+	Error string    `json:"error,omitempty"`
+}
+
+// MarshalJSON encodes the transition with the error as a string
+func (t Transition) MarshalJSON() ([]byte, error) {
+	result := jsonTransition{
+		State: t.State,
+		Time:  t.Time,
+	}
+
+	if t.Err != nil {
+		result.Error = t.Err.Error()
+	}
+
+	return json.Marshal(result)
+}
+
+// UnmarshalJSON decodes the transition, the error only retains its message
+func (t *Transition) UnmarshalJSON(data []byte) error {
+	var result jsonTransition
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+
+	t.State = result.State
+	t.Time = result.Time
+	t.Err = nil
+	if result.Error != "" {
+		t.Err = errors.New(result.Error)
+	}
+
+	return nil
+}
+
+// ModuleStatus of a module in the Controller
+type ModuleStatus struct {
+	Name  string    `json:"name"`
+	State State     `json:"state"`
+	Since time.Time `json:"since"`
+
+	// Error is the most recent error the module failed with
+	Error string `json:"error,omitempty"`
+
+	// Started is true while the module requires stopping
+	Started bool `json:"started"`
+
+	// Timings of the most recent call to each lifecycle phase
+	Timings map[Phase]time.Duration `json:"timings,omitempty"`
+
+	// Transitions of the module, oldest first
+	Transitions []Transition `json:"transitions"`
+}
+
+// Status of the module with the specified name, returns false if no module is found
+func (c *Controller) Status(name string) (ModuleStatus, bool) {
+	c.once.Do(c.init)
+
+	c.lock.RLock()
+	rm, found := c.modules[name]
+	c.lock.RUnlock()
+
+	if !found {
+		return ModuleStatus{}, false
+	}
+
+	return rm.status(), true
+}
+
+// Statuses of all modules in the order they are run
+func (c *Controller) Statuses() []ModuleStatus {
+	modules, err := c.sorted()
+	if err != nil {
+		c.lock.RLock()
+		modules = orderModules(c.modules)
+		c.lock.RUnlock()
+	}
+
+	statuses := make([]ModuleStatus, len(modules))
+	for i, rm := range modules {
+		statuses[i] = rm.status()
+	}
+
+	return statuses
+}
+
+func (rm *moduleReference) status() ModuleStatus {
+	rm.lock.Lock()
+	defer rm.lock.Unlock()
+
+	status := ModuleStatus{
+		Name:        rm.name,
+		Started:     rm.started,
+		Transitions: append([]Transition(nil), rm.transitions...),
+	}
+
+	if len(rm.transitions) > 0 {
+		last := rm.transitions[len(rm.transitions)-1]
+		status.State = last.State
+		status.Since = last.Time
+	}
+
+	for i := len(rm.transitions) - 1; i >= 0; i-- {
+		if rm.transitions[i].Err != nil {
+			status.Error = rm.transitions[i].Err.Error()
+			break
+		}
+	}
+
+	if len(rm.timings) > 0 {
+		status.Timings = make(map[Phase]time.Duration, len(rm.timings))
+		for phase, d := range rm.timings {
+			status.Timings[phase] = d
+		}
+	}
+
+	return status
+}
+
+// transition the module into the state
+func (rm *moduleReference) transition(state State, err error) {
+	rm.lock.Lock()
+	defer rm.lock.Unlock()
+
+	rm.transitions = append(rm.transitions, Transition{State: state, Time: time.Now(), Err: err})
+	if len(rm.transitions) > maxTransitions {
+		rm.transitions = append(rm.transitions[:0], rm.transitions[len(rm.transitions)-maxTransitions:]...)
+	}
+}
+
+func (rm *moduleReference) isStarted() bool {
+	rm.lock.Lock()
+	defer rm.lock.Unlock()
+
+	return rm.started
+}
+
+func (rm *moduleReference) setStarted(started bool) {
+	rm.lock.Lock()
+	defer rm.lock.Unlock()
+
+	rm.started = started
+}
+
+// record the duration of the most recent call to a lifecycle phase
+func (rm *moduleReference) record(phase Phase, d time.Duration) {
+	rm.lock.Lock()
+	defer rm.lock.Unlock()
+
+	if rm.timings == nil {
+		rm.timings = make(map[Phase]time.Duration)
+	}
+
+	rm.timings[phase] = d
+}
@@ -0,0 +1,81 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type preStartModule struct {
+	funcModule
+	err error
+}
+
+func (m *preStartModule) PreStart(context.Context) error {
+	return m.err
+}
+
+func TestControllerStatus(t *testing.T) {
+	c := &Controller{}
+	c.Add("ok", &funcModule{})
+
+	if status, _ := c.Status("ok"); status.State != StateRegistered {
+		t.Errorf("unexpected state: expected %s; got %s", StateRegistered, status.State)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := c.Run(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	status, found := c.Status("ok")
+	if !found {
+		t.Fatal("module status not found")
+	}
+
+	var states []State
+	for _, transition := range status.Transitions {
+		states = append(states, transition.State)
+	}
+
+	expected := []State{StateRegistered, StateInitialized, StateInstalled, StatePreStarted, StateRunning, StateStopping, StateStopped}
+	if !reflect.DeepEqual(states, expected) {
+		t.Errorf("unexpected transitions:\n expected %v\n got      %v", expected, states)
+	}
+
+	if status.State != StateStopped || status.Since != status.Transitions[len(status.Transitions)-1].Time {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	if _, found := c.Status("missing"); found {
+		t.Error("unexpected status for missing module")
+	}
+}
+
+func TestControllerStatusFailed(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	c := &Controller{}
+	c.Add("ok", &funcModule{})
+	c.Add("failing", &preStartModule{err: errFailed})
+
+	if err := c.Run(context.Background()); !errors.Is(err, errFailed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	statuses := c.Statuses()
+	if len(statuses) != 2 {
+		t.Fatalf("unexpected statuses: %+v", statuses)
+	}
+
+	if statuses[0].Name != "ok" || statuses[0].State != StatePreStarted {
+		t.Errorf("unexpected status: %+v", statuses[0])
+	}
+
+	if statuses[1].Name != "failing" || statuses[1].State != StateFailed || statuses[1].Error != errFailed.Error() {
+		t.Errorf("unexpected status: %+v", statuses[1])
+	}
+}
@@ -0,0 +1,199 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// RestartMode of a RestartPolicy
+type RestartMode int
+
+const (
+	// RestartNever escalates the first failure of the module to the Controller
+	RestartNever RestartMode = iota
+
+	// RestartOnFailure restarts the module when it fails until MaxRetries is reached
+	RestartOnFailure
+)
+
+const (
+	// MinRestartBackoff is the shortest delay before restarting a module, so a module that keeps failing is not
+	// restarted in a tight loop
+	MinRestartBackoff = 100 * time.Millisecond
+
+	// DefaultResetAfter is used for restart policies without a ResetAfter
+	DefaultResetAfter = 10 * time.Minute
+)
+
+// RestartPolicy for a Supervised module
+type RestartPolicy struct {
+	Mode RestartMode
+
+	// MaxRetries is the number of restarts before a failure is escalated to the Controller, zero allows unlimited restarts
+	MaxRetries int
+
+	// Backoff is the delay before the first restart, it is doubled for every following restart. It is at least
+	// MinRestartBackoff.
+	Backoff time.Duration
+
+	// MaxBackoff limits the delay between restarts, zero is unlimited
+	MaxBackoff time.Duration
+
+	// ResetAfter is how long a restarted module must run without failing for its restarts to be forgotten, so both
+	// MaxRetries and Backoff start over. DefaultResetAfter is used when zero.
+	ResetAfter time.Duration
+}
+
+// delay before the specified restart attempt (starting at zero)
+func (p RestartPolicy) delay(attempt int) time.Duration {
+	delay := p.Backoff
+	if delay < MinRestartBackoff {
+		delay = MinRestartBackoff
+	}
+
+	for i := 0; i < attempt && delay < math.MaxInt64/2; i++ {
+		if p.MaxBackoff > 0 && delay >= p.MaxBackoff {
+			break
+		}
+
+		delay *= 2
+	}
+
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	if delay < MinRestartBackoff {
+		delay = MinRestartBackoff
+	}
+
+	return delay
+}
+
+// resetAfter returns how long a restarted module must run without failing for its restarts to be forgotten
+func (p RestartPolicy) resetAfter() time.Duration {
+	if p.ResetAfter > 0 {
+		return p.ResetAfter
+	}
+
+	return DefaultResetAfter
+}
+
+// exhausted returns true when the policy does not allow the specified restart attempt (starting at zero)
+func (p RestartPolicy) exhausted(attempt int) bool {
+	return p.Mode == RestartNever || (p.MaxRetries > 0 && attempt >= p.MaxRetries)
+}
+
+// wait for the context to be done while supervising the started modules, returning the failure of the first module that
+// exhausted its RestartPolicy
+func (c *Controller) wait(ctx context.Context, modules []*moduleReference) error {
+	if ctx.Done() == nil {
+		return nil
+	}
+
+	superCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	failCh := make(chan error, 1)
+
+	var wg sync.WaitGroup
+	for _, rm := range modules {
+		supervised, ok := rm.implementation.(Supervised)
+		if !ok || !rm.isStarted() {
+			continue
+		}
+
+		wg.Add(1)
+		go func(rm *moduleReference) {
+			defer wg.Done()
+
+			if err := c.supervise(ctx, superCtx, rm, supervised); err != nil {
+				select {
+				case failCh <- err:
+				default:
+				}
+			}
+		}(rm)
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-failCh:
+	}
+
+	// supervisors must be finished before the modules are stopped
+	cancel()
+	wg.Wait()
+
+	return err
+}
+
+// supervise the module until superCtx is done, restarting it with ctx according to its RestartPolicy
+func (c *Controller) supervise(ctx, superCtx context.Context, rm *moduleReference, supervised Supervised) error {
+	policy := supervised.RestartPolicy()
+	attempt := 0
+	var restarted time.Time
+
+	for {
+		var err error
+		select {
+		case <-superCtx.Done():
+			return nil
+		case err = <-supervised.Failed():
+		}
+
+		// the module exited cleanly, there is nothing more to supervise
+		if err == nil {
+			return nil
+		}
+
+		// a module that ran long enough since its last restart starts over
+		if attempt > 0 && time.Since(restarted) >= policy.resetAfter() {
+			attempt = 0
+		}
+
+		rm.transition(StateFailed, err)
+		c.emit(Event{Type: EventFailed, Module: rm.name, Err: err})
+
+		for err != nil {
+			c.logger.Error("Module failed", "module", rm.name, "error", err)
+
+			if policy.exhausted(attempt) {
+				return fmt.Errorf("module %q failed after %d restarts: %w", rm.name, attempt, err)
+			}
+
+			timer := time.NewTimer(policy.delay(attempt))
+			select {
+			case <-superCtx.Done():
+				timer.Stop()
+				return nil
+			case <-timer.C:
+			}
+
+			attempt++
+			c.logger.Info("Restarting module", "module", rm.name, "attempt", attempt)
+			err = c.restart(ctx, rm)
+			restarted = time.Now()
+		}
+	}
+}
+
+// restart the module by calling Stop then Start
+func (c *Controller) restart(ctx context.Context, rm *moduleReference) error {
+	if rm.isStarted() {
+		rm.setStarted(false)
+		if err := c.call(context.WithoutCancel(ctx), rm, PhaseStop, rm.implementation.Stop); err != nil {
+			c.logger.Warn("Failed to stop module for restart", "module", rm.name, "error", err)
+		}
+	}
+
+	if err := c.call(ctx, rm, PhaseStart, rm.implementation.Start); err != nil {
+		return fmt.Errorf("failed to restart module %q: %w", rm.name, err)
+	}
+	rm.setStarted(true)
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type supervisedModule struct {
+	testModule
+	policy RestartPolicy
+	failed chan error
+}
+
+func (m *supervisedModule) Failed() <-chan error {
+	return m.failed
+}
+
+func (m *supervisedModule) RestartPolicy() RestartPolicy {
+	return m.policy
+}
+
+func TestControllerSupervision(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	rec := &recorder{}
+	m := &supervisedModule{
+		testModule: testModule{name: "worker", recorder: rec},
+		policy:     RestartPolicy{Mode: RestartOnFailure, MaxRetries: 1, Backoff: time.Millisecond},
+		failed:     make(chan error),
+	}
+
+	c := &Controller{}
+	c.Add("worker", m)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error)
+	go func() { done <- c.Run(ctx) }()
+
+	// the first failure restarts the module, the second exhausts the policy
+	for i := 0; i < 2; i++ {
+		select {
+		case m.failed <- errFailed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("module was not supervised")
+		}
+	}
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, errFailed) {
+			t.Errorf("expected escalated failure; got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("failure was not escalated")
+	}
+
+	expected := []string{"start worker", "stop worker", "start worker", "stop worker"}
+	if !reflect.DeepEqual(rec.calls, expected) {
+		t.Errorf("unexpected lifecycle: expected %v; got %v", expected, rec.calls)
+	}
+}
+
+func TestControllerSupervisionReset(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	rec := &recorder{}
+	m := &supervisedModule{
+		testModule: testModule{name: "worker", recorder: rec},
+		policy:     RestartPolicy{Mode: RestartOnFailure, MaxRetries: 1, ResetAfter: 200 * time.Millisecond},
+		failed:     make(chan error),
+	}
+
+	c := &Controller{}
+	c.Add("worker", m)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error)
+	go func() { done <- c.Run(ctx) }()
+
+	fail := func() {
+		select {
+		case m.failed <- errFailed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("module was not supervised")
+		}
+	}
+
+	// the module runs long enough after its restart for the second failure to restart it again, the third failure
+	// follows the restart right away and exhausts the policy
+	fail()
+	time.Sleep(MinRestartBackoff + 300*time.Millisecond)
+	fail()
+	fail()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, errFailed) {
+			t.Errorf("expected escalated failure; got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("failure was not escalated")
+	}
+
+	expected := []string{"start worker", "stop worker", "start worker", "stop worker", "start worker", "stop worker"}
+	if !reflect.DeepEqual(rec.calls, expected) {
+		t.Errorf("unexpected lifecycle: expected %v; got %v", expected, rec.calls)
+	}
+}
+
+func TestRestartPolicyDelay(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Policy   RestartPolicy
+		Attempt  int
+		Expected time.Duration
+	}{
+		{Name: "first", Policy: RestartPolicy{Backoff: time.Second}, Attempt: 0, Expected: time.Second},
+		{Name: "exponential", Policy: RestartPolicy{Backoff: time.Second}, Attempt: 3, Expected: 8 * time.Second},
+		{Name: "max", Policy: RestartPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}, Attempt: 3, Expected: 5 * time.Second},
+		{Name: "overflow", Policy: RestartPolicy{Backoff: time.Second}, Attempt: 1000, Expected: time.Second << 33},
+		{Name: "minimum", Policy: RestartPolicy{}, Attempt: 0, Expected: MinRestartBackoff},
+		{Name: "minimum exponential", Policy: RestartPolicy{}, Attempt: 3, Expected: 8 * MinRestartBackoff},
+		{Name: "minimum max", Policy: RestartPolicy{Backoff: time.Second, MaxBackoff: time.Millisecond}, Attempt: 3, Expected: MinRestartBackoff},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if delay := test.Policy.delay(test.Attempt); delay != test.Expected {
+				t.Errorf("unexpected delay: expected %s; got %s", test.Expected, delay)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package application
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/agext/levenshtein"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/portcullis/application/confighcl"
+)
+
+// unknownKeySummaries are the summaries of the diagnostics of the syntaxes reporting arguments and blocks that are not
+// in the schema of a body
+var unknownKeySummaries = map[string]bool{
+	"Unsupported argument":            true,
+	"Unsupported block type":          true,
+	"Extraneous JSON object property": true,
+}
+
+// keySuggestion is the name of an argument or block that an unknown one may have meant, and the module declaring it
+type keySuggestion struct {
+	name   string
+	module string
+}
+
+// moduleKeys returns the names of the arguments and blocks of the configuration of the module
+func moduleKeys(name string, v interface{}) []keySuggestion {
+	schema, _ := confighcl.ImpliedBodySchema(v)
+
+	keys := make([]keySuggestion, 0, len(schema.Attributes)+len(schema.Blocks))
+	for _, attrS := range schema.Attributes {
+		keys = append(keys, keySuggestion{name: attrS.Name, module: name})
+	}
+	for _, blockS := range schema.Blocks {
+		keys = append(keys, keySuggestion{name: blockS.Type, module: name})
+	}
+
+	return keys
+}
+
+// headerKeys returns the types of the blocks configuring the named modules when module blocks are enabled
+func headerKeys(names []string) []keySuggestion {
+	headers := moduleHeaders(names)
+
+	keys := []keySuggestion{{name: moduleBlockType}}
+	declared := map[string]bool{moduleBlockType: true}
+	for _, name := range names {
+		if header := headers[name]; !declared[header.blockType] {
+			declared[header.blockType] = true
+			keys = append(keys, keySuggestion{name: header.blockType})
+		}
+	}
+
+	return keys
+}
+
+// unknownKeys reports the arguments and blocks remaining in the body, suggesting the closest of the keys. They are
+// warnings when unknown keys are allowed, and errors otherwise.
+func (c *Configuration) unknownKeys(body hcl.Body, keys []keySuggestion) hcl.Diagnostics {
+	_, diags := body.Content(&hcl.BodySchema{})
+
+	for _, diag := range diags {
+		if !unknownKeySummaries[diag.Summary] {
+			continue
+		}
+
+		if c.allowUnknown {
+			diag.Severity = hcl.DiagWarning
+		}
+
+		name, ok := c.keyName(diag.Subject)
+		if !ok {
+			continue
+		}
+
+		if suggestion, ok := closestKey(name, keys); ok {
+			if suggestion.module != "" {
+				diag.Detail += fmt.Sprintf(" Did you mean %q of the %s module?", suggestion.name, suggestion.module)
+			} else {
+				diag.Detail += fmt.Sprintf(" Did you mean %q?", suggestion.name)
+			}
+		}
+	}
+
+	return diags
+}
+
+// keyName returns the name of the argument or block at the subject of a diagnostic, read from the source of its file
+// with the quotes of the JSON, YAML and TOML keys removed
+func (c *Configuration) keyName(subject *hcl.Range) (string, bool) {
+	if subject == nil {
+		return "", false
+	}
+
+	file, ok := c.files[subject.Filename]
+	if !ok {
+		return "", false
+	}
+
+	name := string(subject.SliceBytes(file.Bytes))
+	if unquoted, err := strconv.Unquote(name); err == nil {
+		return unquoted, true
+	}
+
+	return strings.Trim(name, "'"), name != ""
+}
+
+// closestKey returns the key closest to the given name, the first one of the closest keys when several are as close,
+// when it is close enough to be a likely typo
+func closestKey(given string, keys []keySuggestion) (keySuggestion, bool) {
+	var closest keySuggestion
+	distance := 3
+	for _, key := range keys {
+		if d := levenshtein.Distance(given, key.name, nil); d < distance {
+			closest, distance = key, d
+		}
+	}
+
+	return closest, distance < 3
+}
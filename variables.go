@@ -0,0 +1,346 @@
+package application
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/portcullis/application/confighcl"
+	"github.com/portcullis/application/confighcl/funcs"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+const (
+	// variableBlockType is the type of the blocks declaring the variables referred to as var.name
+	variableBlockType = "variable"
+
+	// localsBlockType is the type of the blocks declaring the local values referred to as local.name
+	localsBlockType = "locals"
+)
+
+// variablesSchema is the schema of the variable and locals blocks at the top level of the configuration
+var variablesSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: variableBlockType, LabelNames: []string{"name"}},
+		{Type: localsBlockType},
+	},
+}
+
+// variableSchema is the schema of the body of a variable block
+var variableSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "default"},
+		{Name: "description"},
+	},
+}
+
+// decodeVariables evaluates the variable and locals blocks of the body into the var and local variables of the
+// evaluation context, and returns the remaining body. Local values are evaluated after the ones they refer to.
+func (c *Configuration) decodeVariables(body hcl.Body, evalContext *hcl.EvalContext) (hcl.Body, hcl.Diagnostics) {
+	content, remain, diags := body.PartialContent(variablesSchema)
+
+	vars := map[string]cty.Value{}
+	declared := map[string]*hcl.Block{}
+	locals := map[string]*hcl.Attribute{}
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case variableBlockType:
+			name := block.Labels[0]
+			if existing, ok := declared[name]; ok {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate variable declaration",
+					Detail:   fmt.Sprintf("A variable named %q was already declared at %s.", name, existing.DefRange),
+					Subject:  block.DefRange.Ptr(),
+				})
+				continue
+			}
+			declared[name] = block
+
+			val, valDiags := c.variableValue(block, evalContext)
+			diags = append(diags, valDiags...)
+			vars[name] = val
+
+		case localsBlockType:
+			attrs, attrDiags := block.Body.JustAttributes()
+			diags = append(diags, attrDiags...)
+			for name, attr := range attrs {
+				if existing, ok := locals[name]; ok {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Duplicate local value definition",
+						Detail:   fmt.Sprintf("A local value named %q was already defined at %s.", name, existing.Range),
+						Subject:  attr.NameRange.Ptr(),
+					})
+					continue
+				}
+				locals[name] = attr
+			}
+		}
+	}
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	evalContext.Variables["var"] = cty.ObjectVal(vars)
+	evalContext.Variables["local"] = cty.EmptyObjectVal
+
+	values := map[string]cty.Value{}
+	visiting := map[string]bool{}
+	var path []string
+
+	pure := pureContext(evalContext)
+
+	// evaluate each local value after the local values it refers to, in the order of their names
+	var evaluate func(name string) hcl.Diagnostics
+	evaluate = func(name string) hcl.Diagnostics {
+		if _, ok := values[name]; ok {
+			return nil
+		}
+
+		attr := locals[name]
+		visiting[name] = true
+		path = append(path, "local."+name)
+		defer func() {
+			visiting[name] = false
+			path = path[:len(path)-1]
+		}()
+
+		for _, traversal := range attr.Expr.Variables() {
+			ref, ok := traversalName(traversal, "local")
+			if !ok || locals[ref] == nil {
+				continue
+			}
+
+			if visiting[ref] {
+				return hcl.Diagnostics{{
+					Severity: hcl.DiagError,
+					Summary:  "Cycle in local values",
+					Detail:   fmt.Sprintf("The local value %q refers to itself: %s.", ref, cycle(path, "local."+ref)),
+					Subject:  traversal.SourceRange().Ptr(),
+					Context:  attr.Range.Ptr(),
+				}}
+			}
+
+			if diags := evaluate(ref); diags.HasErrors() {
+				return diags
+			}
+		}
+
+		evalContext.Variables["local"] = cty.ObjectVal(values)
+		val, diags := attr.Expr.Value(pure)
+		if diags.HasErrors() {
+			return diags
+		}
+		values[name] = val
+
+		return diags
+	}
+
+	names := make([]string, 0, len(locals))
+	for name := range locals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if diags = append(diags, evaluate(name)...); diags.HasErrors() {
+			return nil, diags
+		}
+	}
+	evalContext.Variables["local"] = cty.ObjectVal(values)
+
+	return remain, diags
+}
+
+// pureContext returns a child of the evaluation context in which the impure functions such as uuid fail, for the values
+// that must be the same every time they are evaluated: the local values and the configuration of the modules that other
+// modules refer to
+func pureContext(evalContext *hcl.EvalContext) *hcl.EvalContext {
+	child := evalContext.NewChild()
+	child.Functions = make(map[string]function.Function)
+
+	for _, name := range funcs.Impure() {
+		err := fmt.Errorf("%s returns a different value every time it is called, so it cannot be used in local values or the configuration of modules that other modules refer to", name)
+		child.Functions[name] = function.New(&function.Spec{
+			VarParam: &function.Parameter{
+				Name:             "args",
+				Type:             cty.DynamicPseudoType,
+				AllowNull:        true,
+				AllowUnknown:     true,
+				AllowDynamicType: true,
+			},
+			Type: func([]cty.Value) (cty.Type, error) {
+				return cty.NilType, err
+			},
+		})
+	}
+
+	return child
+}
+
+// variableValue returns the value of the variable declared by the block, read from the environment variable named
+// after the variable when environment variable overrides are enabled and otherwise its default
+func (c *Configuration) variableValue(block *hcl.Block, evalContext *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
+	name := block.Labels[0]
+
+	content, diags := block.Body.Content(variableSchema)
+	if diags.HasErrors() {
+		return cty.DynamicVal, diags
+	}
+
+	if c.env {
+		envName := confighcl.EnvName(c.envPrefix, "var", name)
+		if src, ok := os.LookupEnv(envName); ok {
+			return envValue(envName, src), diags
+		}
+	}
+
+	attr, ok := content.Attributes["default"]
+	if !ok {
+		detail := fmt.Sprintf("The variable %q has no default value.", name)
+		if c.env {
+			detail = fmt.Sprintf("The variable %q has no default value and the %s environment variable is not set.", name, confighcl.EnvName(c.envPrefix, "var", name))
+		}
+
+		return cty.DynamicVal, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Missing variable value",
+			Detail:   detail,
+			Subject:  block.DefRange.Ptr(),
+		})
+	}
+
+	val, valDiags := attr.Expr.Value(evalContext)
+	return val, append(diags, valDiags...)
+}
+
+// envValue returns the value of an environment variable setting a variable, the value of a constant HCL expression
+// such as 42 or ["a", "b"] or otherwise the string as is
+func envValue(name, src string) cty.Value {
+	expr, diags := hclsyntax.ParseExpression([]byte(src), name, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() || len(expr.Variables()) > 0 {
+		return cty.StringVal(src)
+	}
+
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return cty.StringVal(src)
+	}
+
+	return val
+}
+
+// traversalName returns the name of the object the traversal refers to in the root variable, such as http for
+// module.http.listen_addr or module["http"].listen_addr, and whether it refers to one
+func traversalName(traversal hcl.Traversal, root string) (string, bool) {
+	if traversal.RootName() != root || len(traversal) < 2 {
+		return "", false
+	}
+
+	switch step := traversal[1].(type) {
+	case hcl.TraverseAttr:
+		return step.Name, true
+	case hcl.TraverseIndex:
+		if step.Key.Type() == cty.String && step.Key.IsKnown() && !step.Key.IsNull() {
+			return step.Key.AsString(), true
+		}
+	}
+
+	return "", false
+}
+
+// cycle returns the references of the path from the given one, which the last reference of the path refers to
+func cycle(path []string, ref string) string {
+	for i, p := range path {
+		if p == ref {
+			path = path[i:]
+			break
+		}
+	}
+
+	return strings.Join(append(append([]string(nil), path...), ref), " -> ")
+}
+
+// moduleOrder returns the configurations of the modules ordered so each module comes after the modules its body refers
+// to as module.name, otherwise keeping the order of the Controller, and the names of the modules referred to. The modules
+// are decoded and notified by ConfigSet in this order.
+func moduleOrder(modules []moduleConfig, body func(name string) hcl.Body) ([]moduleConfig, map[string]bool, hcl.Diagnostics) {
+	byName := make(map[string]moduleConfig, len(modules))
+	for _, m := range modules {
+		byName[m.name] = m
+	}
+
+	order := make([]moduleConfig, 0, len(modules))
+	referenced := map[string]bool{}
+	done := map[string]bool{}
+	visiting := map[string]bool{}
+	var path []string
+
+	var visit func(m moduleConfig) hcl.Diagnostics
+	visit = func(m moduleConfig) hcl.Diagnostics {
+		if done[m.name] {
+			return nil
+		}
+
+		visiting[m.name] = true
+		path = append(path, m.name)
+		defer func() {
+			visiting[m.name] = false
+			path = path[:len(path)-1]
+		}()
+
+		for _, traversal := range confighcl.Variables(body(m.name), m.value) {
+			ref, ok := traversalName(traversal, "module")
+			if !ok {
+				continue
+			}
+
+			// the range of module.name
+			subject := hcl.RangeBetween(traversal[0].SourceRange(), traversal[1].SourceRange())
+
+			dep, ok := byName[ref]
+			if !ok {
+				return hcl.Diagnostics{{
+					Severity: hcl.DiagError,
+					Summary:  "Reference to undeclared module",
+					Detail:   fmt.Sprintf("There is no configurable module named %q.", ref),
+					Subject:  subject.Ptr(),
+				}}
+			}
+
+			referenced[ref] = true
+
+			if visiting[ref] {
+				return hcl.Diagnostics{{
+					Severity: hcl.DiagError,
+					Summary:  "Cycle in module references",
+					Detail:   fmt.Sprintf("The configuration of the %s module refers to itself: %s.", ref, cycle(path, ref)),
+					Subject:  subject.Ptr(),
+				}}
+			}
+
+			if diags := visit(dep); diags.HasErrors() {
+				return diags
+			}
+		}
+
+		done[m.name] = true
+		order = append(order, m)
+
+		return nil
+	}
+
+	for _, m := range modules {
+		if diags := visit(m); diags.HasErrors() {
+			return nil, nil, diags
+		}
+	}
+
+	return order, referenced, nil
+}